@@ -0,0 +1,330 @@
+package bars
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/calendar"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+const DefaultBufferSize = 1000
+
+// DefaultSessionStart is the IST time of day bars are aligned to on days the
+// calendar has no session for the token's exchange
+const DefaultSessionStart = 9*time.Hour + 15*time.Minute
+
+// DefaultGrace is how long a bar stays open past its end for late ticks
+const DefaultGrace = 2 * time.Second
+
+// Bar is one OHLCV candle of a token. Volume is the traded volume within the
+// bar and OI the last open interest seen.
+type Bar struct {
+	Token         uint32        `json:"token"`
+	TradingSymbol string        `json:"tradingsymbol"`
+	Interval      time.Duration `json:"interval"`
+	Start         time.Time     `json:"start"`
+	Candle        *kite.Candle  `json:"candle"`
+}
+
+type barKey struct {
+	token    uint32
+	interval time.Duration
+}
+
+type lastTick struct {
+	tradingSymbol string
+	volume        uint32
+	close         float64
+	oi            uint32
+}
+
+// Builder aggregates the ticks of Ticker into bars of every interval and
+// publishes each bar on Bars when it closes. Bars are aligned to the first
+// session open of the token's exchange that day, so a 60minute NFO bar spans
+// 09:15 to 10:15 as in Kite's historical candles and an MCX one 09:00 to
+// 10:00. Bars close at their end plus Grace whether or not another tick
+// arrives. With FillGaps set an interval without ticks still produces a flat
+// bar at the previous close.
+type Builder struct {
+	Ticker       *kite.TickerClient
+	Tokens       []uint32
+	Intervals    []time.Duration
+	SessionStart time.Duration
+	Grace        time.Duration
+	FillGaps     bool
+	Bars         chan *Bar
+
+	mutex     sync.Mutex
+	open      map[barKey]*Bar
+	emitted   map[barKey]time.Time
+	late      map[barKey]uint64
+	last      map[uint32]*lastTick
+	exchanges map[uint32]string
+}
+
+// NewBuilder returns a builder of the tokens' bars. The ticker should be a
+// dedicated client as the builder drains its TickerChan.
+func NewBuilder(ticker *kite.TickerClient, tokens []uint32, intervals ...time.Duration) *Builder {
+	return &Builder{
+		Ticker:       ticker,
+		Tokens:       tokens,
+		Intervals:    intervals,
+		SessionStart: DefaultSessionStart,
+		Grace:        DefaultGrace,
+		Bars:         make(chan *Bar, DefaultBufferSize),
+		open:         map[barKey]*Bar{},
+		emitted:      map[barKey]time.Time{},
+		late:         map[barKey]uint64{},
+		last:         map[uint32]*lastTick{},
+		exchanges:    map[uint32]string{},
+	}
+}
+
+// Run builds bars until the context is cancelled
+func (b *Builder) Run(ctx *context.Context) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-(*ctx).Done():
+			return
+		case <-b.Ticker.ConnectChan:
+			err := b.Ticker.SubscribeFull(ctx, b.Tokens)
+			if err != nil {
+				log.Errorf("bars : failed to subscribe -> %v", err)
+			}
+		case ticker := <-b.Ticker.TickerChan:
+			b.Add(ticker)
+		case <-tick.C:
+			b.Close(clock.Now())
+		}
+	}
+}
+
+// Add folds the tick into the open bar of every interval. Ticks older than
+// the open bar, or of a bar already published, are late and only count
+// towards the volume of the open or next bar. Volume is measured from the
+// first tick of a token, so the day's volume up to it is only counted when
+// it falls in the session's first bar (or a daily bar); a builder started
+// mid-session under-reports the volume of each token's first bar.
+func (b *Builder) Add(ticker kite.KiteTicker) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	at := tickTime(ticker)
+	last, seen := b.last[ticker.Token]
+	if !seen {
+		last = &lastTick{}
+		b.last[ticker.Token] = last
+	}
+
+	// VolumeTraded is cumulative for the day and restarts with a new session
+	volume := uint32(0)
+	if seen && ticker.VolumeTraded >= last.volume {
+		volume = ticker.VolumeTraded - last.volume
+	} else if seen {
+		volume = ticker.VolumeTraded
+	}
+	if ticker.VolumeTraded > 0 {
+		last.volume = ticker.VolumeTraded
+	}
+	if ticker.TradingSymbol != "" {
+		last.tradingSymbol = ticker.TradingSymbol
+	}
+	if ticker.OI > 0 {
+		last.oi = ticker.OI
+	}
+	if ticker.LastPrice <= 0 {
+		return
+	}
+	last.close = ticker.LastPrice
+
+	origin := b.origin(ticker.Token, at)
+	for _, interval := range b.Intervals {
+		key := barKey{token: ticker.Token, interval: interval}
+		start := align(at, origin, interval)
+		barVolume := volume
+		if !seen && (interval >= 24*time.Hour || start.Equal(origin)) {
+			barVolume = ticker.VolumeTraded
+		}
+		bar, ok := b.open[key]
+		if ok && start.After(bar.Start) {
+			b.emit(bar)
+			ok = false
+		}
+		if ok && start.Before(bar.Start) {
+			bar.Candle.Volume += uint64(barVolume)
+			continue
+		}
+		if emitted, done := b.emitted[key]; !ok && done && !start.After(emitted) {
+			b.late[key] += uint64(barVolume)
+			continue
+		}
+		if !ok {
+			bar = b.newBar(ticker.Token, interval, start, ticker.LastPrice)
+			b.open[key] = bar
+		}
+		candle := bar.Candle
+		if ticker.LastPrice > candle.High {
+			candle.High = ticker.LastPrice
+		}
+		if ticker.LastPrice < candle.Low {
+			candle.Low = ticker.LastPrice
+		}
+		candle.Close = ticker.LastPrice
+		candle.Volume += uint64(barVolume)
+		candle.OI = uint64(last.oi)
+	}
+}
+
+// Close publishes every open bar that ended before now less Grace. With
+// FillGaps set it also publishes flat bars for intervals without ticks, as
+// long as they start within a session of the token's exchange; filling stops
+// at the session close and resumes with the next tick.
+func (b *Builder) Close(now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	keys := []barKey{}
+	for key := range b.open {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].token != keys[j].token {
+			return keys[i].token < keys[j].token
+		}
+		return keys[i].interval < keys[j].interval
+	})
+
+	for _, key := range keys {
+		bar := b.open[key]
+		for !now.Before(bar.Start.Add(key.interval + b.Grace)) {
+			b.emit(bar)
+			delete(b.open, key)
+			if !b.FillGaps {
+				break
+			}
+			next := bar.Start.Add(key.interval)
+			if !b.inSession(key.token, next, key.interval) {
+				break
+			}
+			if !now.Before(next.Add(key.interval + b.Grace)) {
+				bar = b.newBar(key.token, key.interval, next, b.last[key.token].close)
+				continue
+			}
+			b.open[key] = b.newBar(key.token, key.interval, next, b.last[key.token].close)
+			break
+		}
+	}
+}
+
+func (b *Builder) newBar(token uint32, interval time.Duration, start time.Time, price float64) *Bar {
+	last := b.last[token]
+	key := barKey{token: token, interval: interval}
+	volume := b.late[key]
+	delete(b.late, key)
+	return &Bar{
+		Token:         token,
+		TradingSymbol: last.tradingSymbol,
+		Interval:      interval,
+		Start:         start,
+		Candle: &kite.Candle{
+			Timestamp: start.UnixNano(),
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Volume:    volume,
+			OI:        uint64(last.oi),
+		},
+	}
+}
+
+func (b *Builder) emit(bar *Bar) {
+	b.emitted[barKey{token: bar.Token, interval: bar.Interval}] = bar.Start
+	select {
+	case b.Bars <- bar:
+	default:
+		log.Warnf("bars : dropped %v bar of %d at %v, buffer full", bar.Interval, bar.Token, bar.Start)
+	}
+}
+
+// origin returns the first session open of the token's exchange on the IST
+// day of at, or SessionStart of that day when the calendar has none
+func (b *Builder) origin(token uint32, at time.Time) time.Time {
+	at = at.In(kite.IST)
+	sessions, _ := calendar.Default().Sessions(b.exchange(token), at)
+	if len(sessions) > 0 {
+		return sessions[0].Open
+	}
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, kite.IST).Add(b.SessionStart)
+}
+
+// inSession reports whether a bar starting at start falls in a session of
+// the token's exchange, or for daily bars whether that day is a trading day
+func (b *Builder) inSession(token uint32, start time.Time, interval time.Duration) bool {
+	sessions, _ := calendar.Default().Sessions(b.exchange(token), start)
+	if interval >= 24*time.Hour {
+		return len(sessions) > 0
+	}
+	for _, window := range sessions {
+		if !start.Before(window.Open) && start.Before(window.Close) {
+			return true
+		}
+	}
+	return false
+}
+
+// exchange returns the calendar exchange of the token. Index tokens carry no
+// exchange so it comes from the instrument master, defaulting to NSE.
+func (b *Builder) exchange(token uint32) string {
+	if exchange, ok := b.exchanges[token]; ok {
+		return exchange
+	}
+	exchange := kite.SegmentOf(token).String()
+	if kite.SegmentOf(token) == kite.SegmentIndices {
+		exchange = "NSE"
+		if kite.BrokerInstrumentTokens != nil {
+			for _, instrument := range *kite.BrokerInstrumentTokens {
+				if instrument.Token == token {
+					exchange = instrument.Exchange
+					break
+				}
+			}
+		}
+	}
+	b.exchanges[token] = exchange
+	return exchange
+}
+
+// align returns the start of the interval containing at, counted from origin
+func align(at time.Time, origin time.Time, interval time.Duration) time.Time {
+	at = at.In(kite.IST)
+	if interval >= 24*time.Hour {
+		return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, kite.IST)
+	}
+	elapsed := at.Sub(origin)
+	slots := elapsed / interval
+	if elapsed < 0 && elapsed%interval != 0 {
+		slots--
+	}
+	return origin.Add(slots * interval)
+}
+
+// tickTime is the exchange time of the tick, falling back to when it was
+// received for packets without a timestamp
+func tickTime(ticker kite.KiteTicker) time.Time {
+	if ticker.ExchangeTimestamp.Unix() > 0 {
+		return ticker.ExchangeTimestamp
+	}
+	if !ticker.ReceivedAt.IsZero() {
+		return ticker.ReceivedAt
+	}
+	return clock.Now()
+}
@@ -0,0 +1,159 @@
+package bars
+
+import (
+	"testing"
+	"time"
+
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Tokens carry their exchange segment in the low byte. Dates are in the
+// embedded calendar: 2026-10-19 is a regular Monday, 2026-10-20 an NSE
+// holiday with an MCX evening session and 2026-10-17 a Saturday.
+const (
+	nfoToken uint32 = 12345<<8 | uint32(kite.SegmentNSEFO)
+	mcxToken uint32 = 6789<<8 | uint32(kite.SegmentMCXFO)
+)
+
+func at(day int, hour int, minute int, second int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, second, 0, kite.IST)
+}
+
+// drain returns the bars published so far
+func drain(b *Builder) []*Bar {
+	bars := []*Bar{}
+	for {
+		select {
+		case bar := <-b.Bars:
+			bars = append(bars, bar)
+		default:
+			return bars
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	cases := []struct {
+		name     string
+		token    uint32
+		at       time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{"nfo hourly from 09:15", nfoToken, at(19, 10, 20, 0), time.Hour, at(19, 10, 15, 0)},
+		{"mcx hourly from 09:00", mcxToken, at(19, 10, 20, 0), time.Hour, at(19, 10, 0, 0)},
+		{"nfo five minutes", nfoToken, at(19, 9, 17, 30), 5 * time.Minute, at(19, 9, 15, 0)},
+		{"nfo before the open", nfoToken, at(19, 9, 10, 0), time.Hour, at(19, 8, 15, 0)},
+		{"nfo daily", nfoToken, at(19, 14, 0, 0), 24 * time.Hour, at(19, 0, 0, 0)},
+		{"mcx evening only session", mcxToken, at(20, 18, 30, 0), time.Hour, at(20, 18, 0, 0)},
+		{"nfo without a session", nfoToken, at(17, 10, 20, 0), time.Hour, at(17, 10, 15, 0)},
+	}
+	b := NewBuilder(nil, nil)
+	for _, c := range cases {
+		got := align(c.at, b.origin(c.token, c.at), c.interval)
+		if !got.Equal(c.want) {
+			t.Errorf("%s: align = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestLateTicks(t *testing.T) {
+	defer clock.Set(nil)
+	simulated := clock.NewSimulated(at(19, 9, 15, 0))
+	clock.Set(simulated)
+
+	// Each step sets the clock, adds a tick with the exchange timestamp stamp
+	// (none takes the clock) unless price is zero and closes bars at the clock
+	type want struct {
+		start                  time.Time
+		open, high, low, close float64
+		volume                 uint64
+	}
+	steps := []struct {
+		name   string
+		now    time.Time
+		stamp  time.Time
+		price  float64
+		volume uint32
+		closed []want
+	}{
+		{"first tick seeds the day's volume", at(19, 9, 15, 10), time.Time{}, 100, 1000, nil},
+		{"same bar", at(19, 9, 15, 40), time.Time{}, 101, 1010, nil},
+		{"bar closes after grace", at(19, 9, 16, 2), time.Time{}, 0, 0, []want{{at(19, 9, 15, 0), 100, 101, 100, 101, 1010}}},
+		{"late tick of a published bar", at(19, 9, 16, 3), at(19, 9, 15, 50), 90, 1020, nil},
+		{"next bar takes the late volume", at(19, 9, 16, 30), time.Time{}, 102, 1030, nil},
+		{"late tick of the previous bar", at(19, 9, 16, 40), at(19, 9, 15, 59), 80, 1035, nil},
+		{"next bar closes", at(19, 9, 17, 2), time.Time{}, 0, 0, []want{{at(19, 9, 16, 0), 102, 102, 102, 102, 25}}},
+	}
+
+	b := NewBuilder(nil, []uint32{nfoToken}, time.Minute)
+	for _, step := range steps {
+		simulated.Set(step.now)
+		if step.price > 0 {
+			b.Add(kite.KiteTicker{Token: nfoToken, LastPrice: step.price, VolumeTraded: step.volume, ExchangeTimestamp: step.stamp})
+		}
+		b.Close(clock.Now())
+
+		got := drain(b)
+		if len(got) != len(step.closed) {
+			t.Fatalf("%s: published %d bars, want %d", step.name, len(got), len(step.closed))
+		}
+		for i, w := range step.closed {
+			bar := got[i]
+			candle := bar.Candle
+			if !bar.Start.Equal(w.start) || candle.Open != w.open || candle.High != w.high || candle.Low != w.low || candle.Close != w.close || candle.Volume != w.volume {
+				t.Errorf("%s: bar %v %v/%v/%v/%v vol %v, want %v %v/%v/%v/%v vol %v", step.name, bar.Start, candle.Open, candle.High, candle.Low, candle.Close, candle.Volume, w.start, w.open, w.high, w.low, w.close, w.volume)
+			}
+		}
+	}
+	if len(b.open) != 0 {
+		t.Errorf("late ticks left %d bars open", len(b.open))
+	}
+}
+
+func TestCloseFillGaps(t *testing.T) {
+	defer clock.Set(nil)
+	cases := []struct {
+		name     string
+		token    uint32
+		interval time.Duration
+		fillGaps bool
+		tick     time.Time
+		close    time.Time
+		want     []time.Time
+		open     bool
+	}{
+		{"without filling", nfoToken, time.Hour, false, at(19, 10, 20, 0), at(19, 12, 40, 0), []time.Time{at(19, 10, 15, 0)}, false},
+		{"within the session", nfoToken, time.Hour, true, at(19, 10, 20, 0), at(19, 12, 40, 0), []time.Time{at(19, 10, 15, 0), at(19, 11, 15, 0)}, true},
+		{"stops at the close over a holiday", nfoToken, time.Hour, true, at(19, 14, 20, 0), at(21, 10, 0, 0), []time.Time{at(19, 14, 15, 0), at(19, 15, 15, 0)}, false},
+		{"stops at the mcx close", mcxToken, 15 * time.Minute, true, at(19, 23, 10, 0), at(20, 12, 0, 0), []time.Time{at(19, 23, 0, 0), at(19, 23, 15, 0)}, false},
+		{"daily stops at the weekend", nfoToken, 24 * time.Hour, true, at(16, 11, 0, 0), at(19, 12, 0, 0), []time.Time{at(16, 0, 0, 0)}, false},
+	}
+	for _, c := range cases {
+		simulated := clock.NewSimulated(c.tick)
+		clock.Set(simulated)
+		b := NewBuilder(nil, []uint32{c.token}, c.interval)
+		b.FillGaps = c.fillGaps
+		b.Add(kite.KiteTicker{Token: c.token, LastPrice: 100, VolumeTraded: 10})
+
+		simulated.Set(c.close)
+		b.Close(clock.Now())
+		got := drain(b)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: published %d bars, want %d", c.name, len(got), len(c.want))
+			continue
+		}
+		for i, start := range c.want {
+			if !got[i].Start.Equal(start) {
+				t.Errorf("%s: bar %d starts %v, want %v", c.name, i, got[i].Start, start)
+			}
+			if i > 0 && (got[i].Candle.Open != 100 || got[i].Candle.Volume != 0) {
+				t.Errorf("%s: filled bar %d is %+v, want flat at 100", c.name, i, got[i].Candle)
+			}
+		}
+		if open := len(b.open) > 0; open != c.open {
+			t.Errorf("%s: bar left open = %v, want %v", c.name, open, c.open)
+		}
+	}
+}
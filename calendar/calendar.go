@@ -0,0 +1,358 @@
+package calendar
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+//go:embed calendar.json
+var defaultFile []byte
+
+// maxSearchDays bounds the search for the next session or trading day
+const maxSearchDays = 30
+
+// Session is a trading window of a day as IST "HH:MM" times
+type Session struct {
+	Name  string `json:"name,omitempty"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// Exchange defines the sessions of one exchange. Sessions apply to every
+// weekday that is not a holiday, or DSTSessions while DSTZone observes
+// daylight saving time (MCX closes earlier while the US is on summer time).
+// Special lists the sessions of dates that differ, such as muhurat trading
+// or weekend sessions, and takes precedence over holidays and weekends.
+// HolidaysFrom shares the holidays and special sessions of another exchange,
+// with this exchange's own entries taking precedence.
+type Exchange struct {
+	Sessions     []Session            `json:"sessions"`
+	PreOpen      *Session             `json:"pre_open,omitempty"`
+	DSTZone      string               `json:"dst_zone,omitempty"`
+	DSTSessions  []Session            `json:"dst_sessions,omitempty"`
+	HolidaysFrom string               `json:"holidays_from,omitempty"`
+	Holidays     map[string]string    `json:"holidays,omitempty"`
+	Special      map[string][]Session `json:"special,omitempty"`
+
+	dstZone *time.Location
+}
+
+// Window is a session on a given day
+type Window struct {
+	Name  string    `json:"name,omitempty"`
+	Open  time.Time `json:"open"`
+	Close time.Time `json:"close"`
+}
+
+// Calendar holds the exchanges by name as used in instrument keys (NSE, NFO,
+// MCX...). All times are in IST.
+type Calendar struct {
+	Exchanges map[string]*Exchange
+}
+
+var (
+	defaultCalendar *Calendar
+	defaultOnce     sync.Once
+)
+
+// Default returns the calendar of the file named by TA_CALENDAR, falling
+// back to the calendar embedded in the package
+func Default() *Calendar {
+	defaultOnce.Do(func() {
+		if path := os.Getenv("TA_CALENDAR"); path != "" {
+			calendar, err := Load(path)
+			if err == nil {
+				defaultCalendar = calendar
+				return
+			}
+			log.Errorf("calendar : using the embedded calendar, failed to load %v -> %v", path, err)
+		}
+		calendar, err := Parse(defaultFile)
+		if err != nil {
+			log.Panicf("calendar : embedded calendar is invalid -> %v", err)
+		}
+		defaultCalendar = calendar
+	})
+	return defaultCalendar
+}
+
+// SetDefault replaces the calendar used by the package level functions
+func SetDefault(calendar *Calendar) {
+	defaultOnce.Do(func() {})
+	defaultCalendar = calendar
+}
+
+// Load reads a calendar file in the format of the embedded calendar.json
+func Load(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a calendar
+func Parse(data []byte) (*Calendar, error) {
+	exchanges := map[string]*Exchange{}
+	err := json.Unmarshal(data, &exchanges)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, exchange := range exchanges {
+		if exchange.HolidaysFrom == "" {
+			continue
+		}
+		source, ok := exchanges[exchange.HolidaysFrom]
+		if !ok {
+			return nil, errors.New("holidays_exchange_not_found")
+		}
+		holidays := map[string]string{}
+		for date, name := range source.Holidays {
+			holidays[date] = name
+		}
+		for date, name := range exchange.Holidays {
+			holidays[date] = name
+		}
+		special := map[string][]Session{}
+		for date, sessions := range source.Special {
+			special[date] = sessions
+		}
+		for date, sessions := range exchange.Special {
+			special[date] = sessions
+		}
+		exchange.Holidays = holidays
+		exchange.Special = special
+	}
+
+	for _, exchange := range exchanges {
+		sessions := append(append([]Session{}, exchange.Sessions...), exchange.DSTSessions...)
+		if exchange.PreOpen != nil {
+			sessions = append(sessions, *exchange.PreOpen)
+		}
+		for _, special := range exchange.Special {
+			sessions = append(sessions, special...)
+		}
+		for _, session := range sessions {
+			if _, err := timeOfDay(session.Open); err != nil {
+				return nil, err
+			}
+			if _, err := timeOfDay(session.Close); err != nil {
+				return nil, err
+			}
+		}
+		for date := range exchange.Holidays {
+			if _, err := time.ParseInLocation(kite.YYYYMMDD, date, kite.IST); err != nil {
+				return nil, errors.New("invalid_date")
+			}
+		}
+		if exchange.DSTZone != "" {
+			exchange.dstZone, err = time.LoadLocation(exchange.DSTZone)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Calendar{Exchanges: exchanges}, nil
+}
+
+// Sessions returns the trading windows of the exchange on the IST day of t,
+// none on weekends and holidays
+func (c *Calendar) Sessions(exchange string, t time.Time) ([]Window, error) {
+	definition, ok := c.Exchanges[exchange]
+	if !ok {
+		return nil, errors.New("exchange_not_found")
+	}
+	day := startOfDay(t)
+	date := day.Format(kite.YYYYMMDD)
+
+	sessions := definition.Sessions
+	if special, ok := definition.Special[date]; ok {
+		sessions = special
+	} else if _, holiday := definition.Holidays[date]; holiday || isWeekend(day) {
+		return nil, nil
+	} else if definition.dstZone != nil && len(definition.DSTSessions) > 0 && day.Add(12*time.Hour).In(definition.dstZone).IsDST() {
+		sessions = definition.DSTSessions
+	}
+	return windows(day, sessions), nil
+}
+
+// PreOpen returns the pre-open window of the exchange on the IST day of t,
+// if it has one and trades its regular sessions that day
+func (c *Calendar) PreOpen(exchange string, t time.Time) (*Window, error) {
+	definition, ok := c.Exchanges[exchange]
+	if !ok {
+		return nil, errors.New("exchange_not_found")
+	}
+	day := startOfDay(t)
+	if definition.PreOpen == nil || !c.IsTradingDay(exchange, day) {
+		return nil, nil
+	}
+	if _, ok := definition.Special[day.Format(kite.YYYYMMDD)]; ok {
+		return nil, nil
+	}
+	window := windows(day, []Session{*definition.PreOpen})[0]
+	return &window, nil
+}
+
+// IsOpen reports whether the exchange is in a trading session at t
+func (c *Calendar) IsOpen(exchange string, t time.Time) bool {
+	sessions, _ := c.Sessions(exchange, t)
+	for _, window := range sessions {
+		if !t.Before(window.Open) && t.Before(window.Close) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPreOpen reports whether the exchange is in its pre-open session at t
+func (c *Calendar) IsPreOpen(exchange string, t time.Time) bool {
+	window, _ := c.PreOpen(exchange, t)
+	return window != nil && !t.Before(window.Open) && t.Before(window.Close)
+}
+
+// IsTradingDay reports whether the exchange has a session on the IST day
+// of t
+func (c *Calendar) IsTradingDay(exchange string, t time.Time) bool {
+	sessions, _ := c.Sessions(exchange, t)
+	return len(sessions) > 0
+}
+
+// NextOpen returns the start of the first session opening after t
+func (c *Calendar) NextOpen(exchange string, t time.Time) (time.Time, error) {
+	return c.next(exchange, t, func(window Window) time.Time { return window.Open })
+}
+
+// NextClose returns the end of the session in progress at t, or of the
+// next session when the exchange is closed
+func (c *Calendar) NextClose(exchange string, t time.Time) (time.Time, error) {
+	return c.next(exchange, t, func(window Window) time.Time { return window.Close })
+}
+
+func (c *Calendar) next(exchange string, t time.Time, edge func(Window) time.Time) (time.Time, error) {
+	day := startOfDay(t)
+	for i := 0; i <= maxSearchDays; i++ {
+		sessions, err := c.Sessions(exchange, day.AddDate(0, 0, i))
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, window := range sessions {
+			if edge(window).After(t) {
+				return edge(window), nil
+			}
+		}
+	}
+	return time.Time{}, errors.New("session_not_found")
+}
+
+// NextTradingDay returns the start of the first trading day after the IST
+// day of t
+func (c *Calendar) NextTradingDay(exchange string, t time.Time) (time.Time, error) {
+	return c.AddTradingDays(exchange, t, 1)
+}
+
+// PreviousTradingDay returns the start of the last trading day before the
+// IST day of t
+func (c *Calendar) PreviousTradingDay(exchange string, t time.Time) (time.Time, error) {
+	return c.AddTradingDays(exchange, t, -1)
+}
+
+// AddTradingDays moves n trading days from the IST day of t, backwards when
+// n is negative, and returns the start of that day
+func (c *Calendar) AddTradingDays(exchange string, t time.Time, n int) (time.Time, error) {
+	if _, ok := c.Exchanges[exchange]; !ok {
+		return time.Time{}, errors.New("exchange_not_found")
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	day := startOfDay(t)
+	for skipped := 0; n > 0; {
+		day = day.AddDate(0, 0, step)
+		if c.IsTradingDay(exchange, day) {
+			n--
+			skipped = 0
+			continue
+		}
+		skipped++
+		if skipped > maxSearchDays {
+			return time.Time{}, errors.New("trading_day_not_found")
+		}
+	}
+	return day, nil
+}
+
+// TradingDaysBetween counts the trading days after the IST day of from up to
+// and including the IST day of to
+func (c *Calendar) TradingDaysBetween(exchange string, from time.Time, to time.Time) (int, error) {
+	if _, ok := c.Exchanges[exchange]; !ok {
+		return 0, errors.New("exchange_not_found")
+	}
+	count := 0
+	last := startOfDay(to)
+	for day := startOfDay(from).AddDate(0, 0, 1); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if c.IsTradingDay(exchange, day) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IsOpen reports whether the exchange is open at t in the default calendar
+func IsOpen(exchange string, t time.Time) bool {
+	return Default().IsOpen(exchange, t)
+}
+
+// NextOpen returns the next session start in the default calendar
+func NextOpen(exchange string, t time.Time) (time.Time, error) {
+	return Default().NextOpen(exchange, t)
+}
+
+// NextClose returns the next session end in the default calendar
+func NextClose(exchange string, t time.Time) (time.Time, error) {
+	return Default().NextClose(exchange, t)
+}
+
+// IsTradingDay reports whether the exchange trades on the IST day of t in
+// the default calendar
+func IsTradingDay(exchange string, t time.Time) bool {
+	return Default().IsTradingDay(exchange, t)
+}
+
+func windows(day time.Time, sessions []Session) []Window {
+	out := []Window{}
+	for _, session := range sessions {
+		open, _ := timeOfDay(session.Open)
+		closeTime, _ := timeOfDay(session.Close)
+		out = append(out, Window{Name: session.Name, Open: day.Add(open), Close: day.Add(closeTime)})
+	}
+	return out
+}
+
+// timeOfDay parses an "HH:MM" time of day
+func timeOfDay(value string) (time.Duration, error) {
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("invalid_session_time")
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(kite.IST)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, kite.IST)
+}
+
+func isWeekend(day time.Time) bool {
+	return day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+}
@@ -0,0 +1,150 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Dates are in the embedded calendar: 2026-10-19 is a regular Monday,
+// 2026-10-20 an NSE holiday with an MCX evening session and 2026-10-17 a
+// Saturday. The US leaves daylight saving time on 2026-11-01.
+
+func at(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, kite.IST)
+}
+
+func embedded(t *testing.T) *Calendar {
+	t.Helper()
+	calendar, err := Parse(defaultFile)
+	if err != nil {
+		t.Fatalf("embedded calendar: %v", err)
+	}
+	return calendar
+}
+
+func TestSessions(t *testing.T) {
+	calendar := embedded(t)
+	cases := []struct {
+		name     string
+		exchange string
+		day      time.Time
+		want     []Window
+	}{
+		{"regular day", "NSE", at(2026, 10, 19, 12, 0), []Window{{"", at(2026, 10, 19, 9, 15), at(2026, 10, 19, 15, 30)}}},
+		{"weekend", "NSE", at(2026, 10, 17, 12, 0), nil},
+		{"holiday", "NSE", at(2026, 10, 20, 12, 0), nil},
+		{"holiday from nse", "NFO", at(2026, 10, 20, 12, 0), nil},
+		{"special from nse", "NFO", at(2025, 10, 21, 12, 0), []Window{{"muhurat", at(2025, 10, 21, 13, 45), at(2025, 10, 21, 14, 45)}}},
+		{"mcx evening session", "MCX", at(2026, 10, 20, 12, 0), []Window{{"evening", at(2026, 10, 20, 17, 0), at(2026, 10, 20, 23, 30)}}},
+		{"mcx during us dst", "MCX", at(2026, 10, 30, 12, 0), []Window{{"", at(2026, 10, 30, 9, 0), at(2026, 10, 30, 23, 30)}}},
+		{"mcx after us dst", "MCX", at(2026, 11, 2, 12, 0), []Window{{"", at(2026, 11, 2, 9, 0), at(2026, 11, 2, 23, 55)}}},
+		{"utc time on the ist day", "NSE", time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC), []Window{{"", at(2026, 10, 19, 9, 15), at(2026, 10, 19, 15, 30)}}},
+	}
+	for _, c := range cases {
+		got, err := calendar.Sessions(c.exchange, c.day)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: %d sessions, want %d", c.name, len(got), len(c.want))
+			continue
+		}
+		for i, want := range c.want {
+			if got[i].Name != want.Name || !got[i].Open.Equal(want.Open) || !got[i].Close.Equal(want.Close) {
+				t.Errorf("%s: session %d is %v %v-%v, want %v %v-%v", c.name, i, got[i].Name, got[i].Open, got[i].Close, want.Name, want.Open, want.Close)
+			}
+		}
+	}
+
+	if _, err := calendar.Sessions("XYZ", at(2026, 10, 19, 12, 0)); err == nil {
+		t.Errorf("unknown exchange: no error")
+	}
+}
+
+func TestNextOpenAndClose(t *testing.T) {
+	calendar, err := Parse([]byte(`{
+		"NSE": {"sessions": [{"open": "09:15", "close": "15:30"}], "holidays": {"2026-10-20": "Dussehra"}},
+		"SPLIT": {"sessions": [{"open": "09:00", "close": "12:00"}, {"open": "13:00", "close": "17:00"}], "holidays_from": "NSE"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name     string
+		exchange string
+		now      time.Time
+		open     bool
+		next     time.Time
+		close    time.Time
+	}{
+		{"before the open", "SPLIT", at(2026, 10, 19, 8, 0), false, at(2026, 10, 19, 9, 0), at(2026, 10, 19, 12, 0)},
+		{"first session", "SPLIT", at(2026, 10, 19, 10, 0), true, at(2026, 10, 19, 13, 0), at(2026, 10, 19, 12, 0)},
+		{"between sessions", "SPLIT", at(2026, 10, 19, 12, 30), false, at(2026, 10, 19, 13, 0), at(2026, 10, 19, 17, 0)},
+		{"second session", "SPLIT", at(2026, 10, 19, 13, 0), true, at(2026, 10, 21, 9, 0), at(2026, 10, 19, 17, 0)},
+		{"at the close over a holiday", "SPLIT", at(2026, 10, 19, 17, 0), false, at(2026, 10, 21, 9, 0), at(2026, 10, 21, 12, 0)},
+		{"over the weekend", "NSE", at(2026, 10, 16, 16, 0), false, at(2026, 10, 19, 9, 15), at(2026, 10, 19, 15, 30)},
+	}
+	for _, c := range cases {
+		if open := calendar.IsOpen(c.exchange, c.now); open != c.open {
+			t.Errorf("%s: open = %v, want %v", c.name, open, c.open)
+		}
+		next, err := calendar.NextOpen(c.exchange, c.now)
+		if err != nil || !next.Equal(c.next) {
+			t.Errorf("%s: next open = %v %v, want %v", c.name, next, err, c.next)
+		}
+		closeTime, err := calendar.NextClose(c.exchange, c.now)
+		if err != nil || !closeTime.Equal(c.close) {
+			t.Errorf("%s: next close = %v %v, want %v", c.name, closeTime, err, c.close)
+		}
+	}
+}
+
+func TestTradingDays(t *testing.T) {
+	calendar := embedded(t)
+	day, err := calendar.AddTradingDays("NFO", at(2026, 10, 16, 12, 0), 2)
+	if err != nil || !day.Equal(at(2026, 10, 21, 0, 0)) {
+		t.Errorf("add trading days = %v %v, want %v", day, err, at(2026, 10, 21, 0, 0))
+	}
+	day, err = calendar.PreviousTradingDay("NFO", at(2026, 10, 21, 12, 0))
+	if err != nil || !day.Equal(at(2026, 10, 19, 0, 0)) {
+		t.Errorf("previous trading day = %v %v, want %v", day, err, at(2026, 10, 19, 0, 0))
+	}
+	count, err := calendar.TradingDaysBetween("MCX", at(2026, 10, 16, 12, 0), at(2026, 10, 21, 12, 0))
+	if err != nil || count != 3 {
+		t.Errorf("mcx trading days between = %v %v, want 3", count, err)
+	}
+}
+
+func TestPreOpen(t *testing.T) {
+	calendar := embedded(t)
+	if !calendar.IsPreOpen("NSE", at(2026, 10, 19, 9, 5)) {
+		t.Errorf("nse not in pre-open at 09:05")
+	}
+	if calendar.IsPreOpen("NSE", at(2025, 10, 21, 9, 5)) {
+		t.Errorf("nse in pre-open on a special session day")
+	}
+	if calendar.IsPreOpen("NFO", at(2026, 10, 19, 9, 5)) {
+		t.Errorf("nfo in pre-open")
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+	}{
+		{"missing holidays exchange", `{"NFO": {"sessions": [{"open": "09:15", "close": "15:30"}], "holidays_from": "NSE"}}`},
+		{"invalid session time", `{"NSE": {"sessions": [{"open": "9.15", "close": "15:30"}]}}`},
+		{"invalid special time", `{"NSE": {"sessions": [], "special": {"2026-11-08": [{"open": "18:00", "close": "25:00"}]}}}`},
+		{"invalid holiday", `{"NSE": {"sessions": [], "holidays": {"08-11-2026": "Diwali"}}}`},
+		{"invalid dst zone", `{"MCX": {"sessions": [], "dst_zone": "Nowhere/Else"}}`},
+	}
+	for _, c := range cases {
+		if _, err := Parse([]byte(c.data)); err == nil {
+			t.Errorf("%s: no error", c.name)
+		}
+	}
+}
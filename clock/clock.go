@@ -0,0 +1,101 @@
+package clock
+
+import (
+	"sync"
+	"time"
+	_ "time/tzdata"
+)
+
+// IST is Asia/Kolkata, the time zone of the Indian exchanges
+var IST = loadIST()
+
+func loadIST() *time.Location {
+	location, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		return time.FixedZone("IST", 5*3600+1800)
+	}
+	return location
+}
+
+// Clock tells the current time in IST. Time-dependent logic such as trading
+// sessions, expiries, TOTPs and daily file names reads it instead of
+// time.Now, so tests and replays can run at a simulated time. Timeouts and
+// elapsed time measurements keep using the wall clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the host's clock, in IST whatever the host's zone
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().In(IST)
+}
+
+// Simulated is a clock that only moves when set or advanced
+type Simulated struct {
+	mutex sync.RWMutex
+	now   time.Time
+}
+
+func NewSimulated(now time.Time) *Simulated {
+	return &Simulated{now: now.In(IST)}
+}
+
+func (s *Simulated) Now() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.now
+}
+
+// Set moves the clock to now
+func (s *Simulated) Set(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.now = now.In(IST)
+}
+
+// Advance moves the clock forward by d
+func (s *Simulated) Advance(d time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.now = s.now.Add(d)
+}
+
+var (
+	mutex   sync.RWMutex
+	current Clock = System{}
+)
+
+// Set replaces the clock used by Now, nil restores the system clock
+func Set(clock Clock) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if clock == nil {
+		clock = System{}
+	}
+	current = clock
+}
+
+// Current returns the clock in use
+func Current() Clock {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+// Now is the current time in IST on the clock in use
+func Now() time.Time {
+	return Current().Now()
+}
+
+// Since is the time elapsed on the clock in use since t
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Today is the start of the current IST day
+func Today() time.Time {
+	now := Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, IST)
+}
@@ -1,7 +1,6 @@
 package engine
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
 	"encoding/json"
@@ -15,7 +14,8 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
+	"github.com/souvik131/kite-go-library/calendar"
+	"github.com/souvik131/kite-go-library/clock"
 	"github.com/souvik131/kite-go-library/kite"
 	"github.com/souvik131/kite-go-library/storage"
 	"google.golang.org/protobuf/proto"
@@ -23,7 +23,7 @@ import (
 
 var dateFormatConcise = "20060102"
 
-func Write(ctx *context.Context, k *kite.Kite) {
+func Write(ctx *context.Context, k kite.Streamer) {
 
 	Serve(ctx, k)
 	wg := &sync.WaitGroup{}
@@ -40,7 +40,7 @@ func readMap(dateStr string) (map[uint32]*storage.TickerMap, error) {
 	}
 	for len(b) > 8 {
 		sizeOfPacket := binary.BigEndian.Uint64(b[0:8])
-		packet, err := decompress(b[8 : sizeOfPacket+8])
+		packet, err := storage.Decompress(b[8 : sizeOfPacket+8])
 		if err != nil {
 			return nil, err
 		}
@@ -76,7 +76,7 @@ func Read(dateStr string) {
 	go func() {
 		for len(b) > 8 {
 			sizeOfPacket := binary.BigEndian.Uint64(b[0:8])
-			packet, err := decompress(b[8 : sizeOfPacket+8])
+			packet, err := storage.Decompress(b[8 : sizeOfPacket+8])
 			if err != nil {
 				log.Printf("%s", err)
 			}
@@ -110,55 +110,16 @@ func Read(dateStr string) {
 			for key := range indices {
 				keys = append(keys, key)
 			}
-			fmt.Printf("Read", counter, "F&O records of ("+strings.Join(keys, ", ")+")", "in", timeElapsed)
+			fmt.Println("Read", counter, "F&O records of ("+strings.Join(keys, ", ")+")", "in", timeElapsed)
 			log.Panic("exiting")
 		}
 	}
 
 }
 
-func compress(input []byte) ([]byte, error) {
-	var b bytes.Buffer
-	bestLevel := zstd.WithEncoderLevel(zstd.SpeedBestCompression)
-	encoder, err := zstd.NewWriter(&b, bestLevel)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = encoder.Write(input)
-	if err != nil {
-		encoder.Close()
-		return nil, err
-	}
-
-	err = encoder.Close()
-	if err != nil {
-		return nil, err
-	}
-
-	return b.Bytes(), nil
-}
-
-func decompress(input []byte) ([]byte, error) {
-	b := bytes.NewReader(input)
-	decoder, err := zstd.NewReader(b)
-	if err != nil {
-		return nil, err
-	}
-	defer decoder.Close()
-
-	var out bytes.Buffer
-	_, err = out.ReadFrom(decoder)
-	if err != nil {
-		return nil, err
-	}
-
-	return out.Bytes(), nil
-}
-
 func appendToFile(filename string, data []byte) error {
 
-	compressedData, err := compress(data)
+	compressedData, err := storage.Compress(data)
 	if err != nil {
 		log.Printf("%s", err)
 	}
@@ -180,7 +141,7 @@ func appendToFile(filename string, data []byte) error {
 }
 
 func saveFile(filePath string, data []byte) error {
-	compressedData, err := compress(data)
+	compressedData, err := storage.Compress(data)
 	if err != nil {
 		log.Printf("%s", err)
 	}
@@ -198,7 +159,7 @@ func saveFile(filePath string, data []byte) error {
 	return err
 }
 
-func Serve(ctx *context.Context, k *kite.Kite) {
+func Serve(ctx *context.Context, k kite.Streamer) {
 
 	tokenTradingsymbolMap := map[uint32]*storage.TickerMap{}
 
@@ -227,31 +188,37 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 		log.Printf("%s", err)
 	}
 
-	saveFile("./binary/map_"+time.Now().Format(dateFormatConcise)+".proto.zstd", bytes)
+	saveFile("./binary/map_"+clock.Now().Format(dateFormatConcise)+".proto.zstd", bytes)
 
 	log.Printf("Instrument Map successfully written to file")
 
-	k.TickerClients = []*kite.TickerClient{}
 	// Initialize token tracking
 	var processedTokens int64 = 0
 	processedSymbols := make(map[string]bool)
 
 	allTokens := []uint32{}
-	// Track tokens by segment for different trading hours
-	equityTokens := make(map[uint32]bool)
-	mcxTokens := make(map[uint32]bool)
+	// Track tokens by exchange as each trades its own calendar sessions
+	tokenExchanges := make(map[uint32]string)
+	exchangeTokenCounts := make(map[string]int)
 
 	for _, data := range *kite.BrokerInstrumentTokens {
 		if data.Exchange == "NSE" || data.Exchange == "NFO" || data.Exchange == "NFO-OPT" || data.Name == "SENSEX" || data.Name == "BANKEX" || data.Segment == "MCX-FUT" {
 			allTokens = append(allTokens, data.Token)
+			tokenExchanges[data.Token] = data.Exchange
+			exchangeTokenCounts[data.Exchange]++
+		}
+	}
 
-			// Track token by segment
-			if data.Segment == "MCX-FUT" {
-				mcxTokens[data.Token] = true
-			} else {
-				equityTokens[data.Token] = true
+	// openExchanges returns the exchanges in a trading session now
+	openExchanges := func() map[string]bool {
+		now := clock.Now()
+		open := map[string]bool{}
+		for exchange := range exchangeTokenCounts {
+			if calendar.IsOpen(exchange, now) {
+				open[exchange] = true
 			}
 		}
+		return open
 	}
 	totalTokens := len(allTokens)
 	log.Printf("Total unique tokens to process: %d", totalTokens)
@@ -261,8 +228,8 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 	if err != nil {
 		log.Printf("%v", err)
 	}
-	k.TickerClients = append(k.TickerClients, ticker)
-	k.TickSymbolMap = map[string]kite.KiteTicker{}
+	ticker.ReceiveBinaryTickers = true
+	k.AddTickerClient(ticker)
 
 	rotationInterval, err := strconv.ParseFloat(os.Getenv("TA_FEED_TIMEOUT"), 64)
 	if err != nil {
@@ -287,9 +254,7 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 						return
 					default:
 
-						nowTimeValue := time.Now().Hour()*60 + time.Now().Minute()
-						withinEquityTradingTime := 9*60+15 <= nowTimeValue && nowTimeValue <= 15*60+30
-						withinMCXTradingTime := 9*60 <= nowTimeValue && nowTimeValue <= 23*60+30
+						open := openExchanges()
 						// Rotate through all tokens in chunks
 						for start := 0; start < len(allTokens); start += int(instrumentsPerRequest) {
 							select {
@@ -297,21 +262,15 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 								return
 							default:
 
-								// Function to check if a token is within its trading hours based on segment
+								// Function to check if a token's exchange is in a trading session
 								withinTradingTime := func(token uint32) bool {
-									if mcxTokens[token] {
-										return withinMCXTradingTime
-									}
-									return withinEquityTradingTime
+									return open[tokenExchanges[token]]
 								}
 
 								// Calculate active tokens count for progress tracking
 								activeTokensCount := 0
-								if withinEquityTradingTime {
-									activeTokensCount += len(equityTokens)
-								}
-								if withinMCXTradingTime {
-									activeTokensCount += len(mcxTokens)
+								for exchange := range open {
+									activeTokensCount += exchangeTokenCounts[exchange]
 								}
 								// Update totalTokens to reflect only active tokens
 								totalTokens = activeTokensCount
@@ -360,16 +319,8 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 		for ticker := range t.TickerChan {
 			symbolsMutex.Lock()
 
-			// Populate TickSymbolMap for quote functionality using dedicated mutex
-			k.TickSymbolMapMutex.Lock()
-			if ticker.TradingSymbol != "" {
-				k.TickSymbolMap[ticker.TradingSymbol] = ticker
-			}
-			// Also store with exchange:symbol format
-			if tokenSymbol, exists := kite.TokenSymbolMap[ticker.Token]; exists {
-				k.TickSymbolMap[tokenSymbol] = ticker
-			}
-			k.TickSymbolMapMutex.Unlock()
+			// Populate TickSymbolMap for quote functionality
+			k.StoreTick(ticker)
 
 			if !processedSymbols[ticker.TradingSymbol] {
 				processedSymbols[ticker.TradingSymbol] = true
@@ -396,13 +347,9 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 	// Handle binary data
 	go func(t *kite.TickerClient) {
 		for message := range t.BinaryTickerChan {
-			nowTimeValue := time.Now().Hour()*60 + time.Now().Minute()
-			withinEquityTradingTime := 9*60+15 <= nowTimeValue && nowTimeValue <= 15*60+30
-			withinMCXTradingTime := 9*60 <= nowTimeValue && nowTimeValue <= 23*60+30
-
-			// Save data if either equity or MCX is trading
-			if withinEquityTradingTime || withinMCXTradingTime {
-				appendToFile("./binary/market_data_equity_mcx_"+time.Now().Format(dateFormatConcise)+".bin.zstd", message)
+			// Save data if any tracked exchange is trading
+			if len(openExchanges()) > 0 {
+				appendToFile("./binary/market_data_equity_mcx_"+clock.Now().Format(dateFormatConcise)+".bin.zstd", message)
 			}
 
 			// data := &storage.Data{
@@ -431,15 +378,18 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 					if len(values) >= 2 {
 						ticker.Token = values[0]
 						ticker.LastPrice = values[1]
+						ticker.Segment = uint32(kite.SegmentOf(ticker.Token))
+						ticker.Divisor = uint32(kite.PriceDivisor(ticker.Token))
 					}
 					switch len(values) {
 					case 2:
 					case 7:
+						// Index quote : high, low, open, close, change
 						ticker.High = values[2]
 						ticker.Low = values[3]
 						ticker.Open = values[4]
 						ticker.Close = values[5]
-						ticker.ExchangeTimestamp = values[6]
+						ticker.PriceChange = values[6]
 					case 8:
 						ticker.High = values[2]
 						ticker.Low = values[3]
@@ -453,9 +403,10 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 						ticker.VolumeTraded = values[4]
 						ticker.TotalBuy = values[5]
 						ticker.TotalSell = values[6]
-						ticker.High = values[7]
-						ticker.Low = values[8]
-						ticker.Open = values[9]
+						// Quote and full packets carry open, high, low, close in that order
+						ticker.Open = values[7]
+						ticker.High = values[8]
+						ticker.Low = values[9]
 						ticker.Close = values[10]
 					case 16:
 						ticker.LastTradedQuantity = values[2]
@@ -463,9 +414,10 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 						ticker.VolumeTraded = values[4]
 						ticker.TotalBuy = values[5]
 						ticker.TotalSell = values[6]
-						ticker.High = values[7]
-						ticker.Low = values[8]
-						ticker.Open = values[9]
+						// Quote and full packets carry open, high, low, close in that order
+						ticker.Open = values[7]
+						ticker.High = values[8]
+						ticker.Low = values[9]
 						ticker.Close = values[10]
 						ticker.LastTradedTimestamp = values[11]
 						ticker.OI = values[12]
@@ -473,7 +425,7 @@ func Serve(ctx *context.Context, k *kite.Kite) {
 						ticker.OILow = values[14]
 						ticker.ExchangeTimestamp = values[15]
 					default:
-						log.Printf("unkown length of packet", len(values), values)
+						log.Println("unkown length of packet", len(values), values)
 					}
 
 					if len(packet) > 64 {
@@ -0,0 +1,188 @@
+package greeks
+
+import (
+	"context"
+	"time"
+
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+	"github.com/souvik131/kite-go-library/options"
+)
+
+const DefaultRiskFreeRate = options.DefaultRiskFreeRate
+
+type Greeks = options.Greeks
+
+// PositionGreeks are the Greeks of one net position. Unit holds the Greeks
+// of a single unit and Greeks the position total, signed by quantity. Stale
+// is set when the last price, and so the implied volatility, is the previous
+// close because no live price was available.
+type PositionGreeks struct {
+	Exchange        string  `json:"exchange"`
+	TradingSymbol   string  `json:"tradingsymbol"`
+	InstrumentType  string  `json:"instrument_type"`
+	Underlying      string  `json:"underlying"`
+	UnderlyingPrice float64 `json:"underlying_price"`
+	Strike          float64 `json:"strike"`
+	Expiry          string  `json:"expiry"`
+	Quantity        int64   `json:"quantity"`
+	Units           float64 `json:"units"`
+	Lots            float64 `json:"lots"`
+	LastPrice       float64 `json:"last_price"`
+	TimeToExpiry    float64 `json:"time_to_expiry"`
+	ImpliedVol      float64 `json:"implied_volatility"`
+	Unit            *Greeks `json:"unit"`
+	Greeks          *Greeks `json:"greeks"`
+	Stale           bool    `json:"stale"`
+	Error           string  `json:"error,omitempty"`
+
+	instrument *kite.Instrument
+}
+
+// Book is the Greeks of every position with totals by underlying
+type Book struct {
+	Positions    []*PositionGreeks  `json:"positions"`
+	ByUnderlying map[string]*Greeks `json:"by_underlying"`
+	Total        *Greeks            `json:"total"`
+}
+
+// Calculator computes the Greeks of the broker's net positions. Option
+// Greeks come from the options package with volatility implied from the
+// option's last price; futures and cash positions carry delta only.
+type Calculator struct {
+	Broker        kite.Broker
+	RiskFreeRate  float64
+	DividendYield float64
+}
+
+// NewCalculator returns a calculator using the rates of
+// options.ConfigFromEnv
+func NewCalculator(broker kite.Broker) *Calculator {
+	config := options.ConfigFromEnv()
+	return &Calculator{
+		Broker:        broker,
+		RiskFreeRate:  config.RiskFreeRate,
+		DividendYield: config.DividendYield,
+	}
+}
+
+// Compute loads the net positions and returns their Greeks
+func (c *Calculator) Compute(ctx *context.Context) (*Book, error) {
+	positions, err := c.Broker.GetPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	book := &Book{
+		Positions:    []*PositionGreeks{},
+		ByUnderlying: map[string]*Greeks{},
+		Total:        &Greeks{},
+	}
+	prices := map[string]float64{}
+	now := clock.Now()
+	for _, position := range positions.Net {
+		if position.Quantity == 0 {
+			continue
+		}
+		pg := c.positionGreeks(ctx, position, prices, now)
+		book.Positions = append(book.Positions, pg)
+
+		total, ok := book.ByUnderlying[pg.Underlying]
+		if !ok {
+			total = &Greeks{}
+			book.ByUnderlying[pg.Underlying] = total
+		}
+		add(total, pg.Greeks)
+		add(book.Total, pg.Greeks)
+	}
+	return book, nil
+}
+
+func (c *Calculator) positionGreeks(ctx *context.Context, position *kite.Position, prices map[string]float64, now time.Time) *PositionGreeks {
+	multiplier := position.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	pg := &PositionGreeks{
+		Exchange:       position.Exchange,
+		TradingSymbol:  position.TradingSymbol,
+		InstrumentType: "EQ",
+		Underlying:     position.Exchange + ":" + position.TradingSymbol,
+		Quantity:       position.Quantity,
+		Units:          float64(position.Quantity * multiplier),
+		Lots:           float64(position.Quantity),
+		LastPrice:      position.LastPrice,
+		Stale:          position.PriceFallback,
+		Unit:           &Greeks{Delta: 1},
+		Greeks:         &Greeks{},
+	}
+
+	if kite.BrokerInstrumentTokens != nil {
+		instrument, ok := (*kite.BrokerInstrumentTokens)[position.Exchange+":"+position.TradingSymbol]
+		if ok {
+			pg.instrument = instrument
+			pg.InstrumentType = instrument.InstrumentType
+			pg.Underlying = kite.UnderlyingOf(instrument)
+			pg.Strike = instrument.Strike
+			pg.Expiry = instrument.Expiry
+			if instrument.LotSize > 0 {
+				pg.Lots = pg.Units / instrument.LotSize
+			}
+		}
+	} else {
+		pg.Error = "instruments_not_loaded"
+	}
+
+	if pg.InstrumentType == "CE" || pg.InstrumentType == "PE" {
+		pg.Unit = &Greeks{}
+		c.optionGreeks(ctx, pg, prices, now)
+	}
+	pg.Greeks = &Greeks{
+		Delta: pg.Unit.Delta * pg.Units,
+		Gamma: pg.Unit.Gamma * pg.Units,
+		Vega:  pg.Unit.Vega * pg.Units,
+		Theta: pg.Unit.Theta * pg.Units,
+		Rho:   pg.Unit.Rho * pg.Units,
+	}
+	return pg
+}
+
+func (c *Calculator) optionGreeks(ctx *context.Context, pg *PositionGreeks, prices map[string]float64, now time.Time) {
+	if pg.Underlying == "" {
+		pg.Error = "underlying_not_found"
+		return
+	}
+	spot, ok := prices[pg.Underlying]
+	if !ok {
+		exchange, tradingSymbol := kite.SplitKey(pg.Underlying)
+		price, err := c.Broker.GetLastPrice(ctx, exchange, tradingSymbol)
+		if err != nil || price <= 0 {
+			pg.Error = "underlying_price_unavailable"
+			return
+		}
+		spot = price
+		prices[pg.Underlying] = spot
+	}
+	pg.UnderlyingPrice = spot
+
+	config := &options.Config{RiskFreeRate: c.RiskFreeRate, DividendYield: c.DividendYield}
+	analysis, err := config.Analyze(pg.instrument, pg.LastPrice, spot, now)
+	if analysis == nil {
+		pg.Error = err.Error()
+		return
+	}
+	pg.TimeToExpiry = analysis.TimeToExpiry
+	pg.ImpliedVol = analysis.ImpliedVol
+	pg.Unit = analysis.Greeks
+	if err != nil && pg.TimeToExpiry > 0 {
+		pg.Error = "implied_volatility_not_found"
+	}
+}
+
+func add(total *Greeks, other *Greeks) {
+	total.Delta += other.Delta
+	total.Gamma += other.Gamma
+	total.Vega += other.Vega
+	total.Theta += other.Theta
+	total.Rho += other.Rho
+}
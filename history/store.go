@@ -0,0 +1,206 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+	"github.com/souvik131/kite-go-library/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+const dateFormatConcise = "20060102"
+
+// Fetcher downloads candles from the broker
+type Fetcher interface {
+	GetHistoricalCandles(ctx *context.Context, token uint32, interval kite.Interval, from time.Time, to time.Time, continuous bool) ([]*kite.Candle, error)
+}
+
+// Store keeps historical candles on disk as one zstd compressed protobuf
+// file per token, interval and IST day. Only days before today are stored,
+// so today's partial session is always fetched again.
+type Store struct {
+	Dir     string
+	Fetcher Fetcher
+}
+
+func NewStore(dir string, fetcher Fetcher) *Store {
+	return &Store{Dir: dir, Fetcher: fetcher}
+}
+
+// GetHistoricalData resolves the trading symbol and returns its candles, see
+// GetHistoricalCandles
+func (s *Store) GetHistoricalData(ctx *context.Context, exchange string, tradingSymbol string, interval kite.Interval, from time.Time, to time.Time, continuous bool) ([]*kite.Candle, error) {
+	if kite.BrokerInstrumentTokens == nil {
+		return nil, fmt.Errorf("instrument tokens not loaded")
+	}
+	symbolKey := exchange + ":" + tradingSymbol
+	instrument, exists := (*kite.BrokerInstrumentTokens)[symbolKey]
+	if !exists {
+		return nil, fmt.Errorf("instrument %s not found", symbolKey)
+	}
+	return s.GetHistoricalCandles(ctx, instrument.Token, interval, from, to, continuous)
+}
+
+// GetHistoricalCandles serves the cached days of the range and fetches the
+// missing ones, one call per contiguous gap. Fetched days before today are
+// saved, including days without candles so holidays are not fetched again.
+func (s *Store) GetHistoricalCandles(ctx *context.Context, token uint32, interval kite.Interval, from time.Time, to time.Time, continuous bool) ([]*kite.Candle, error) {
+	if _, ok := kite.MaxHistoricalDays[interval]; !ok {
+		return nil, errors.New("interval_not_allowed")
+	}
+	if to.Before(from) {
+		return nil, errors.New("invalid_date_range")
+	}
+
+	today := startOfDay(clock.Now())
+	candles := []*kite.Candle{}
+	gap := []time.Time{}
+	for day := startOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		cached, err := s.load(token, interval, continuous, day)
+		if err == nil && day.Before(today) {
+			if len(gap) > 0 {
+				fetched, err := s.fetch(ctx, token, interval, continuous, gap, today)
+				if err != nil {
+					return nil, err
+				}
+				candles = append(candles, fetched...)
+				gap = []time.Time{}
+			}
+			candles = append(candles, cached...)
+			continue
+		}
+		gap = append(gap, day)
+	}
+	if len(gap) > 0 {
+		fetched, err := s.fetch(ctx, token, interval, continuous, gap, today)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, fetched...)
+	}
+
+	inRange := []*kite.Candle{}
+	for _, candle := range candles {
+		if candle.Timestamp >= from.UnixNano() && candle.Timestamp <= to.UnixNano() {
+			inRange = append(inRange, candle)
+		}
+	}
+	return inRange, nil
+}
+
+// fetch downloads the whole days of a contiguous gap and saves the ones
+// before today
+func (s *Store) fetch(ctx *context.Context, token uint32, interval kite.Interval, continuous bool, days []time.Time, today time.Time) ([]*kite.Candle, error) {
+	from := days[0]
+	to := days[len(days)-1].AddDate(0, 0, 1).Add(-time.Second)
+	candles, err := s.Fetcher.GetHistoricalCandles(ctx, token, interval, from, to, continuous)
+	if err != nil {
+		return nil, err
+	}
+
+	byDay := map[string][]*kite.Candle{}
+	for _, candle := range candles {
+		date := time.Unix(0, candle.Timestamp).In(kite.IST).Format(dateFormatConcise)
+		byDay[date] = append(byDay[date], candle)
+	}
+	for _, day := range days {
+		if !day.Before(today) {
+			continue
+		}
+		err = s.save(token, interval, continuous, day, byDay[day.Format(dateFormatConcise)])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return candles, nil
+}
+
+func (s *Store) load(token uint32, interval kite.Interval, continuous bool, day time.Time) ([]*kite.Candle, error) {
+	data, err := os.ReadFile(s.path(token, interval, continuous, day))
+	if err != nil {
+		return nil, err
+	}
+	packet, err := storage.Decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	candleDay := &storage.CandleDay{}
+	err = proto.Unmarshal(packet, candleDay)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := []*kite.Candle{}
+	for _, c := range candleDay.Candles {
+		candles = append(candles, &kite.Candle{
+			Timestamp: c.Timestamp,
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Volume:    c.Volume,
+			OI:        c.OI,
+		})
+	}
+	return candles, nil
+}
+
+func (s *Store) save(token uint32, interval kite.Interval, continuous bool, day time.Time, candles []*kite.Candle) error {
+	candleDay := &storage.CandleDay{
+		Token:    token,
+		Interval: string(interval),
+		Date:     day.Format(dateFormatConcise),
+		Candles:  []*storage.Candle{},
+	}
+	for _, c := range candles {
+		candleDay.Candles = append(candleDay.Candles, &storage.Candle{
+			Timestamp: c.Timestamp,
+			Open:      c.Open,
+			High:      c.High,
+			Low:       c.Low,
+			Close:     c.Close,
+			Volume:    c.Volume,
+			OI:        c.OI,
+		})
+	}
+	packet, err := proto.Marshal(candleDay)
+	if err != nil {
+		return err
+	}
+	data, err := storage.Compress(packet)
+	if err != nil {
+		return err
+	}
+
+	path := s.path(token, interval, continuous, day)
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so a crash never leaves a torn day
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) path(token uint32, interval kite.Interval, continuous bool, day time.Time) string {
+	series := string(interval)
+	if continuous {
+		series += "_continuous"
+	}
+	return filepath.Join(s.Dir, fmt.Sprint(token), series, day.Format(dateFormatConcise)+".proto.zstd")
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(kite.IST)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, kite.IST)
+}
@@ -0,0 +1,189 @@
+package indicators
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Batch functions return one value per input aligned with it, NaN where the
+// indicator is not ready yet. They run the streaming versions so both always
+// agree.
+
+func Closes(candles []*kite.Candle) []float64 {
+	closes := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.Close
+	}
+	return closes
+}
+
+func SMA(values []float64, period int) []float64 {
+	stream := NewSMAStream(period)
+	out := make([]float64, len(values))
+	for i, value := range values {
+		out[i] = stream.Add(value)
+	}
+	return out
+}
+
+func EMA(values []float64, period int) []float64 {
+	stream := NewEMAStream(period)
+	out := make([]float64, len(values))
+	for i, value := range values {
+		out[i] = stream.Add(value)
+	}
+	return out
+}
+
+func RSI(values []float64, period int) []float64 {
+	stream := NewRSIStream(period)
+	out := make([]float64, len(values))
+	for i, value := range values {
+		out[i] = stream.Add(value)
+	}
+	return out
+}
+
+func MACD(values []float64, fast int, slow int, signal int) []MACDValue {
+	stream := NewMACDStream(fast, slow, signal)
+	out := make([]MACDValue, len(values))
+	for i, value := range values {
+		out[i] = stream.Add(value)
+	}
+	return out
+}
+
+func Bollinger(values []float64, period int, width float64) []BollingerValue {
+	stream := NewBollingerStream(period, width)
+	out := make([]BollingerValue, len(values))
+	for i, value := range values {
+		out[i] = stream.Add(value)
+	}
+	return out
+}
+
+func ATR(candles []*kite.Candle, period int) []float64 {
+	stream := NewATRStream(period)
+	out := make([]float64, len(candles))
+	for i, candle := range candles {
+		out[i] = stream.Add(candle)
+	}
+	return out
+}
+
+func Supertrend(candles []*kite.Candle, period int, multiplier float64) []SupertrendValue {
+	stream := NewSupertrendStream(period, multiplier)
+	out := make([]SupertrendValue, len(candles))
+	for i, candle := range candles {
+		out[i] = stream.Add(candle)
+	}
+	return out
+}
+
+func VWAP(candles []*kite.Candle) []float64 {
+	stream := NewVWAPStream()
+	out := make([]float64, len(candles))
+	for i, candle := range candles {
+		out[i] = stream.Add(candle)
+	}
+	return out
+}
+
+// Params configures Compute. Zero values take the usual defaults: period 14
+// (20 for Bollinger, 10 for Supertrend), MACD 12/26/9, Bollinger width 2 and
+// Supertrend multiplier 3.
+type Params struct {
+	Period     int     `json:"period"`
+	Fast       int     `json:"fast"`
+	Slow       int     `json:"slow"`
+	Signal     int     `json:"signal"`
+	Width      float64 `json:"width"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// Point is the indicator's values at one candle
+type Point struct {
+	Time   time.Time          `json:"time"`
+	Values map[string]float64 `json:"values"`
+}
+
+// Names of the indicators Compute supports
+var Names = []string{"sma", "ema", "rsi", "macd", "bollinger", "atr", "supertrend", "vwap"}
+
+// Compute runs the named indicator over the candles and returns a point for
+// every candle where it is ready
+func Compute(name string, candles []*kite.Candle, params Params) ([]*Point, error) {
+	period := func(fallback int) int {
+		if params.Period > 0 {
+			return params.Period
+		}
+		return fallback
+	}
+	orDefault := func(value int, fallback int) int {
+		if value > 0 {
+			return value
+		}
+		return fallback
+	}
+	orDefaultFloat := func(value float64, fallback float64) float64 {
+		if value > 0 {
+			return value
+		}
+		return fallback
+	}
+
+	values := make([]map[string]float64, len(candles))
+	switch name {
+	case "sma", "ema", "rsi", "atr", "vwap":
+		var series []float64
+		switch name {
+		case "sma":
+			series = SMA(Closes(candles), period(14))
+		case "ema":
+			series = EMA(Closes(candles), period(14))
+		case "rsi":
+			series = RSI(Closes(candles), period(14))
+		case "atr":
+			series = ATR(candles, period(14))
+		case "vwap":
+			series = VWAP(candles)
+		}
+		for i, value := range series {
+			values[i] = map[string]float64{name: value}
+		}
+	case "macd":
+		series := MACD(Closes(candles), orDefault(params.Fast, 12), orDefault(params.Slow, 26), orDefault(params.Signal, 9))
+		for i, value := range series {
+			values[i] = map[string]float64{"macd": value.MACD, "signal": value.Signal, "histogram": value.Histogram}
+		}
+	case "bollinger":
+		series := Bollinger(Closes(candles), period(20), orDefaultFloat(params.Width, 2))
+		for i, value := range series {
+			values[i] = map[string]float64{"middle": value.Middle, "upper": value.Upper, "lower": value.Lower}
+		}
+	case "supertrend":
+		series := Supertrend(candles, period(10), orDefaultFloat(params.Multiplier, 3))
+		for i, value := range series {
+			values[i] = map[string]float64{"supertrend": value.Supertrend, "direction": float64(value.Direction)}
+		}
+	default:
+		return nil, errors.New("indicator_not_supported")
+	}
+
+	points := []*Point{}
+	for i, candle := range candles {
+		ready := true
+		for _, value := range values[i] {
+			if math.IsNaN(value) {
+				ready = false
+			}
+		}
+		if ready {
+			points = append(points, &Point{Time: time.Unix(0, candle.Timestamp).In(kite.IST), Values: values[i]})
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,187 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Reference series and values from the StockCharts ChartSchool examples.
+// The EMA example publishes closes rounded to cents, so its averages are
+// only compared to within a cent.
+
+var emaCloses = []float64{
+	22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+	22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+	23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+}
+
+var rsiCloses = []float64{
+	44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+	45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+	46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+	43.4205, 42.6628, 43.1314,
+}
+
+func assertSeries(t *testing.T, name string, got []float64, offset int, want []float64, tolerance float64) {
+	t.Helper()
+	for i := 0; i < offset; i++ {
+		if !math.IsNaN(got[i]) {
+			t.Errorf("%s[%d] = %v, want NaN before warm up", name, i, got[i])
+		}
+	}
+	for i, value := range want {
+		if math.Abs(got[offset+i]-value) > tolerance {
+			t.Errorf("%s[%d] = %.4f, want %.4f", name, offset+i, got[offset+i], value)
+		}
+	}
+}
+
+func TestSMA(t *testing.T) {
+	want := []float64{
+		22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08,
+		23.21, 23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28, 23.13,
+	}
+	assertSeries(t, "sma", SMA(emaCloses, 10), 9, want, 0.01)
+}
+
+func TestEMA(t *testing.T) {
+	want := []float64{
+		22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28,
+		23.34, 23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+	}
+	assertSeries(t, "ema", EMA(emaCloses, 10), 9, want, 0.01)
+}
+
+func TestRSI(t *testing.T) {
+	want := []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}
+	assertSeries(t, "rsi", RSI(rsiCloses, 14), 14, want, 0.01)
+}
+
+func TestMACDOfLinearSeries(t *testing.T) {
+	// An EMA seeded with the SMA tracks a straight line (period-1)/2 behind,
+	// so MACD(12, 26) of 1, 2, 3... is exactly 7 with a flat signal line
+	values := []float64{}
+	for i := 1; i <= 60; i++ {
+		values = append(values, float64(i))
+	}
+	series := MACD(values, 12, 26, 9)
+	for i, value := range series {
+		if i < 33 {
+			if !math.IsNaN(value.Signal) {
+				t.Errorf("signal[%d] = %v, want NaN before warm up", i, value.Signal)
+			}
+			continue
+		}
+		if math.Abs(value.MACD-7) > 1e-9 || math.Abs(value.Signal-7) > 1e-9 || math.Abs(value.Histogram) > 1e-9 {
+			t.Errorf("macd[%d] = %+v, want 7/7/0", i, value)
+		}
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	series := Bollinger([]float64{1, 2, 3, 4, 5, 6}, 5, 2)
+	want := []BollingerValue{
+		{Middle: 3, Upper: 3 + 2*math.Sqrt2, Lower: 3 - 2*math.Sqrt2},
+		{Middle: 4, Upper: 4 + 2*math.Sqrt2, Lower: 4 - 2*math.Sqrt2},
+	}
+	for i, value := range want {
+		got := series[4+i]
+		if math.Abs(got.Middle-value.Middle) > 1e-9 || math.Abs(got.Upper-value.Upper) > 1e-9 || math.Abs(got.Lower-value.Lower) > 1e-9 {
+			t.Errorf("bollinger[%d] = %+v, want %+v", 4+i, got, value)
+		}
+	}
+}
+
+func candles(rows [][4]float64, volume uint64, start time.Time, step time.Duration) []*kite.Candle {
+	out := []*kite.Candle{}
+	for i, row := range rows {
+		out = append(out, &kite.Candle{
+			Timestamp: start.Add(time.Duration(i) * step).UnixNano(),
+			Open:      row[0],
+			High:      row[1],
+			Low:       row[2],
+			Close:     row[3],
+			Volume:    volume,
+		})
+	}
+	return out
+}
+
+func TestATR(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, kite.IST)
+	series := ATR(candles([][4]float64{
+		{10, 12, 9, 11},  // TR 3
+		{11, 13, 10, 12}, // TR 3
+		{12, 15, 12, 14}, // TR 3
+		{14, 14, 8, 9},   // TR 6
+		{9, 10, 9, 10},   // TR 1
+	}, 1, start, time.Minute), 3)
+	// Seeded with the mean of the first three ranges, then Wilder smoothed
+	assertSeries(t, "atr", series, 2, []float64{3, 4, 3}, 1e-9)
+}
+
+func TestVWAPRestartsEachDay(t *testing.T) {
+	day := time.Date(2024, 1, 1, 9, 15, 0, 0, kite.IST)
+	series := VWAP([]*kite.Candle{
+		{Timestamp: day.UnixNano(), High: 12, Low: 9, Close: 12, Volume: 100},
+		{Timestamp: day.Add(time.Minute).UnixNano(), High: 15, Low: 12, Close: 15, Volume: 300},
+		{Timestamp: day.AddDate(0, 0, 1).UnixNano(), High: 21, Low: 18, Close: 21, Volume: 50},
+	})
+	assertSeries(t, "vwap", series, 0, []float64{11, 13.25, 20}, 1e-9)
+}
+
+func TestSupertrendFlipsOnReversal(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, kite.IST)
+	rows := [][4]float64{}
+	price := 100.0
+	for i := 0; i < 15; i++ {
+		rows = append(rows, [4]float64{price, price + 1, price - 1, price + 0.5})
+		price += 1
+	}
+	for i := 0; i < 15; i++ {
+		rows = append(rows, [4]float64{price, price + 1, price - 1, price - 0.5})
+		price -= 3
+	}
+	series := Supertrend(candles(rows, 1, start, time.Minute), 5, 2)
+	if series[14].Direction != 1 || series[14].Supertrend >= rows[14][3] {
+		t.Errorf("supertrend[14] = %+v, want an uptrend below the close", series[14])
+	}
+	if series[29].Direction != -1 || series[29].Supertrend <= rows[29][3] {
+		t.Errorf("supertrend[29] = %+v, want a downtrend above the close", series[29])
+	}
+}
+
+func TestStreamsMatchBatch(t *testing.T) {
+	stream := NewRSIStream(14)
+	batch := RSI(rsiCloses, 14)
+	for i, value := range rsiCloses {
+		got := stream.Add(value)
+		if !(math.IsNaN(got) && math.IsNaN(batch[i])) && got != batch[i] {
+			t.Fatalf("stream[%d] = %v, batch %v", i, got, batch[i])
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, kite.IST)
+	rows := [][4]float64{}
+	for _, value := range emaCloses {
+		rows = append(rows, [4]float64{value, value, value, value})
+	}
+	points, err := Compute("sma", candles(rows, 1, start, time.Minute), Params{Period: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(points) != len(emaCloses)-9 || math.Abs(points[0].Values["sma"]-22.22) > 0.005 {
+		t.Errorf("compute sma returned %d points starting %v", len(points), points[0].Values)
+	}
+	if _, err := Compute("unknown", nil, Params{}); err == nil {
+		t.Error("compute accepted an unknown indicator")
+	}
+}
@@ -0,0 +1,379 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Streams are fed one value or candle at a time, for example from the bars
+// of a bars.Builder. Add returns the latest value, NaN until Ready.
+
+type SMAStream struct {
+	period int
+	window []float64
+	sum    float64
+}
+
+func NewSMAStream(period int) *SMAStream {
+	return &SMAStream{period: period}
+}
+
+func (s *SMAStream) Add(value float64) float64 {
+	s.window = append(s.window, value)
+	s.sum += value
+	if len(s.window) > s.period {
+		s.sum -= s.window[0]
+		s.window = s.window[1:]
+	}
+	return s.Value()
+}
+
+func (s *SMAStream) Ready() bool {
+	return s.period > 0 && len(s.window) == s.period
+}
+
+func (s *SMAStream) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	return s.sum / float64(s.period)
+}
+
+// EMAStream is seeded with the SMA of the first period values
+type EMAStream struct {
+	period int
+	alpha  float64
+	seed   *SMAStream
+	value  float64
+	ready  bool
+}
+
+func NewEMAStream(period int) *EMAStream {
+	return &EMAStream{
+		period: period,
+		alpha:  2 / float64(period+1),
+		seed:   NewSMAStream(period),
+	}
+}
+
+func (s *EMAStream) Add(value float64) float64 {
+	if s.ready {
+		s.value += s.alpha * (value - s.value)
+		return s.value
+	}
+	s.seed.Add(value)
+	if s.seed.Ready() {
+		s.value = s.seed.Value()
+		s.ready = true
+	}
+	return s.Value()
+}
+
+func (s *EMAStream) Ready() bool {
+	return s.ready
+}
+
+func (s *EMAStream) Value() float64 {
+	if !s.ready {
+		return math.NaN()
+	}
+	return s.value
+}
+
+// wilder is Wilder's smoothing: the mean of the first period values, then
+// value = (previous*(period-1) + next) / period
+type wilder struct {
+	period int
+	count  int
+	sum    float64
+	value  float64
+}
+
+func (w *wilder) add(value float64) {
+	w.count++
+	if w.count <= w.period {
+		w.sum += value
+		w.value = w.sum / float64(w.period)
+		return
+	}
+	w.value = (w.value*float64(w.period-1) + value) / float64(w.period)
+}
+
+func (w *wilder) ready() bool {
+	return w.period > 0 && w.count >= w.period
+}
+
+// RSIStream is Wilder's relative strength index
+type RSIStream struct {
+	gain     wilder
+	loss     wilder
+	previous float64
+	started  bool
+}
+
+func NewRSIStream(period int) *RSIStream {
+	return &RSIStream{gain: wilder{period: period}, loss: wilder{period: period}}
+}
+
+func (s *RSIStream) Add(value float64) float64 {
+	if s.started {
+		change := value - s.previous
+		s.gain.add(math.Max(change, 0))
+		s.loss.add(math.Max(-change, 0))
+	}
+	s.previous = value
+	s.started = true
+	return s.Value()
+}
+
+func (s *RSIStream) Ready() bool {
+	return s.gain.ready()
+}
+
+func (s *RSIStream) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	if s.loss.value == 0 {
+		if s.gain.value == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+s.gain.value/s.loss.value)
+}
+
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// MACDStream is the fast EMA less the slow EMA with an EMA signal line over
+// the MACD values
+type MACDStream struct {
+	fast   *EMAStream
+	slow   *EMAStream
+	signal *EMAStream
+	macd   float64
+}
+
+func NewMACDStream(fast int, slow int, signal int) *MACDStream {
+	return &MACDStream{
+		fast:   NewEMAStream(fast),
+		slow:   NewEMAStream(slow),
+		signal: NewEMAStream(signal),
+		macd:   math.NaN(),
+	}
+}
+
+func (s *MACDStream) Add(value float64) MACDValue {
+	fast := s.fast.Add(value)
+	slow := s.slow.Add(value)
+	if s.fast.Ready() && s.slow.Ready() {
+		s.macd = fast - slow
+		s.signal.Add(s.macd)
+	}
+	return s.Value()
+}
+
+func (s *MACDStream) Ready() bool {
+	return s.signal.Ready()
+}
+
+func (s *MACDStream) Value() MACDValue {
+	signal := s.signal.Value()
+	return MACDValue{MACD: s.macd, Signal: signal, Histogram: s.macd - signal}
+}
+
+type BollingerValue struct {
+	Middle float64
+	Upper  float64
+	Lower  float64
+}
+
+// BollingerStream is the SMA with bands width standard deviations away,
+// using the population standard deviation of the window
+type BollingerStream struct {
+	sma   *SMAStream
+	width float64
+}
+
+func NewBollingerStream(period int, width float64) *BollingerStream {
+	return &BollingerStream{sma: NewSMAStream(period), width: width}
+}
+
+func (s *BollingerStream) Add(value float64) BollingerValue {
+	s.sma.Add(value)
+	return s.Value()
+}
+
+func (s *BollingerStream) Ready() bool {
+	return s.sma.Ready()
+}
+
+func (s *BollingerStream) Value() BollingerValue {
+	if !s.Ready() {
+		return BollingerValue{Middle: math.NaN(), Upper: math.NaN(), Lower: math.NaN()}
+	}
+	mean := s.sma.Value()
+	variance := 0.0
+	for _, value := range s.sma.window {
+		variance += (value - mean) * (value - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(s.sma.window)))
+	return BollingerValue{
+		Middle: mean,
+		Upper:  mean + s.width*deviation,
+		Lower:  mean - s.width*deviation,
+	}
+}
+
+// ATRStream is Wilder's average true range. The first true range is the
+// high less the low.
+type ATRStream struct {
+	average   wilder
+	prevClose float64
+	started   bool
+}
+
+func NewATRStream(period int) *ATRStream {
+	return &ATRStream{average: wilder{period: period}}
+}
+
+func (s *ATRStream) Add(candle *kite.Candle) float64 {
+	trueRange := candle.High - candle.Low
+	if s.started {
+		trueRange = math.Max(trueRange, math.Max(math.Abs(candle.High-s.prevClose), math.Abs(candle.Low-s.prevClose)))
+	}
+	s.average.add(trueRange)
+	s.prevClose = candle.Close
+	s.started = true
+	return s.Value()
+}
+
+func (s *ATRStream) Ready() bool {
+	return s.average.ready()
+}
+
+func (s *ATRStream) Value() float64 {
+	if !s.Ready() {
+		return math.NaN()
+	}
+	return s.average.value
+}
+
+type SupertrendValue struct {
+	Supertrend float64
+	// Direction is 1 in an uptrend and -1 in a downtrend
+	Direction int
+}
+
+// SupertrendStream trails a band multiplier ATRs from the candle midpoint
+// below price in an uptrend and above it in a downtrend
+type SupertrendStream struct {
+	atr        *ATRStream
+	multiplier float64
+	upper      float64
+	lower      float64
+	prevClose  float64
+	value      SupertrendValue
+}
+
+func NewSupertrendStream(period int, multiplier float64) *SupertrendStream {
+	return &SupertrendStream{
+		atr:        NewATRStream(period),
+		multiplier: multiplier,
+		value:      SupertrendValue{Supertrend: math.NaN()},
+	}
+}
+
+func (s *SupertrendStream) Add(candle *kite.Candle) SupertrendValue {
+	atr := s.atr.Add(candle)
+	if !s.atr.Ready() {
+		s.prevClose = candle.Close
+		return s.value
+	}
+
+	mid := (candle.High + candle.Low) / 2
+	upper := mid + s.multiplier*atr
+	lower := mid - s.multiplier*atr
+	if s.value.Direction != 0 {
+		// The bands only tighten while price stays inside them
+		if upper > s.upper && s.prevClose <= s.upper {
+			upper = s.upper
+		}
+		if lower < s.lower && s.prevClose >= s.lower {
+			lower = s.lower
+		}
+	}
+
+	switch {
+	case s.value.Direction == 0:
+		s.value.Direction = 1
+		if candle.Close < mid {
+			s.value.Direction = -1
+		}
+	case s.value.Direction == 1 && candle.Close < lower:
+		s.value.Direction = -1
+	case s.value.Direction == -1 && candle.Close > upper:
+		s.value.Direction = 1
+	}
+	s.value.Supertrend = lower
+	if s.value.Direction == -1 {
+		s.value.Supertrend = upper
+	}
+
+	s.upper = upper
+	s.lower = lower
+	s.prevClose = candle.Close
+	return s.value
+}
+
+func (s *SupertrendStream) Ready() bool {
+	return s.atr.Ready()
+}
+
+func (s *SupertrendStream) Value() SupertrendValue {
+	return s.value
+}
+
+// VWAPStream is the volume weighted typical price (high+low+close)/3,
+// restarting with every IST trading day
+type VWAPStream struct {
+	day    string
+	volume float64
+	value  float64
+	vwap   float64
+}
+
+func NewVWAPStream() *VWAPStream {
+	return &VWAPStream{vwap: math.NaN()}
+}
+
+func (s *VWAPStream) Add(candle *kite.Candle) float64 {
+	day := time.Unix(0, candle.Timestamp).In(kite.IST).Format(kite.YYYYMMDD)
+	if day != s.day {
+		s.day = day
+		s.volume = 0
+		s.value = 0
+		s.vwap = math.NaN()
+	}
+	typical := (candle.High + candle.Low + candle.Close) / 3
+	s.volume += float64(candle.Volume)
+	s.value += typical * float64(candle.Volume)
+	if s.volume > 0 {
+		s.vwap = s.value / s.volume
+	}
+	return s.vwap
+}
+
+func (s *VWAPStream) Ready() bool {
+	return !math.IsNaN(s.vwap)
+}
+
+func (s *VWAPStream) Value() float64 {
+	return s.vwap
+}
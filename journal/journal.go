@@ -0,0 +1,139 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+const dateFormat = "2006-01-02"
+
+// Entry is everything the broker reported for one trading day
+type Entry struct {
+	Date       string                `json:"date"`
+	RecordedAt time.Time             `json:"recorded_at"`
+	Orders     []*kite.OrderStatus   `json:"orders"`
+	Trades     []*kite.Trade         `json:"trades"`
+	Charges    []*kite.BrokerCharges `json:"charges"`
+}
+
+// Journal stores one JSON file per day in Dir so reports can be rebuilt
+// without calling the broker again
+type Journal struct {
+	Dir string
+}
+
+func New(dir string) *Journal {
+	return &Journal{Dir: dir}
+}
+
+// Record fetches the day's orders, fills and charges from the broker and
+// writes them to the journal under today's date
+func (j *Journal) Record(ctx *context.Context, broker kite.Broker) (*Entry, error) {
+	orders, err := broker.GetOrders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := broker.GetTrades(ctx)
+	if err != nil {
+		return nil, err
+	}
+	charges, err := broker.GetOrderCharges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now().In(kite.IST)
+	entry := &Entry{
+		Date:       now.Format(dateFormat),
+		RecordedAt: now,
+		Orders:     orders,
+		Trades:     trades,
+		Charges:    charges,
+	}
+	return entry, j.Save(entry)
+}
+
+// Save writes the entry, replacing any earlier entry of the same date
+func (j *Journal) Save(entry *Entry) error {
+	if _, err := time.Parse(dateFormat, entry.Date); err != nil {
+		return errors.New("journal_invalid_date")
+	}
+	err := os.MkdirAll(j.Dir, 0o755)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a torn entry
+	path := j.path(entry.Date)
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the entry of a single date
+func (j *Journal) Load(date string) (*Entry, error) {
+	data, err := os.ReadFile(j.path(date))
+	if err != nil {
+		return nil, err
+	}
+	var entry *Entry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// LoadRange reads every entry from the date from to the date to, both
+// inclusive, in date order. Empty bounds are open.
+func (j *Journal) LoadRange(from string, to string) ([]*Entry, error) {
+	files, err := os.ReadDir(j.Dir)
+	if err != nil {
+		return nil, err
+	}
+	dates := []string{}
+	for _, file := range files {
+		date, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok || file.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(dateFormat, date); err != nil {
+			continue
+		}
+		if (from != "" && date < from) || (to != "" && date > to) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	entries := []*Entry{}
+	for _, date := range dates {
+		entry, err := j.Load(date)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (j *Journal) path(date string) string {
+	return filepath.Join(j.Dir, date+".json")
+}
@@ -0,0 +1,289 @@
+package journal
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Holding period classifications of a realised trade. Derivatives are
+// reported as FNO whatever the holding period.
+const (
+	Intraday = "INTRADAY"
+	STCG     = "STCG"
+	LTCG     = "LTCG"
+	FNO      = "FNO"
+)
+
+// LongTermDays is the holding period after which equity gains are long term
+const LongTermDays = 365
+
+// Realisation is one FIFO match of an opening fill against a closing fill
+type Realisation struct {
+	Exchange       string  `csv:"exchange" json:"exchange"`
+	TradingSymbol  string  `csv:"tradingsymbol" json:"tradingsymbol"`
+	Quantity       float64 `csv:"quantity" json:"quantity"`
+	BuyDate        string  `csv:"buy_date" json:"buy_date"`
+	BuyPrice       float64 `csv:"buy_price" json:"buy_price"`
+	SellDate       string  `csv:"sell_date" json:"sell_date"`
+	SellPrice      float64 `csv:"sell_price" json:"sell_price"`
+	HoldingDays    int     `csv:"holding_days" json:"holding_days"`
+	Classification string  `csv:"classification" json:"classification"`
+	Pnl            float64 `csv:"pnl" json:"pnl"`
+}
+
+// InstrumentSummary totals the realised P&L, turnover and charges of one
+// instrument
+type InstrumentSummary struct {
+	Exchange      string  `csv:"exchange" json:"exchange"`
+	TradingSymbol string  `csv:"tradingsymbol" json:"tradingsymbol"`
+	Intraday      float64 `csv:"intraday" json:"intraday"`
+	STCG          float64 `csv:"stcg" json:"stcg"`
+	LTCG          float64 `csv:"ltcg" json:"ltcg"`
+	FNO           float64 `csv:"fno" json:"fno"`
+	Realised      float64 `csv:"realised" json:"realised"`
+	Turnover      float64 `csv:"turnover" json:"turnover"`
+	Charges       float64 `csv:"charges" json:"charges"`
+}
+
+// Lot is an open quantity left after matching, negative for shorts
+type Lot struct {
+	Exchange      string  `csv:"exchange" json:"exchange"`
+	TradingSymbol string  `csv:"tradingsymbol" json:"tradingsymbol"`
+	Date          string  `csv:"date" json:"date"`
+	Quantity      float64 `csv:"quantity" json:"quantity"`
+	Price         float64 `csv:"price" json:"price"`
+}
+
+// Report holds the realised P&L of a period. Unmatched lists delivery sells
+// that closed no buy in the journal, such as shares bought before it was
+// started; their cost basis is unknown so they are left out of the P&L.
+type Report struct {
+	From         string               `json:"from"`
+	To           string               `json:"to"`
+	Realisations []*Realisation       `json:"realisations"`
+	Instruments  []*InstrumentSummary `json:"instruments"`
+	OpenLots     []*Lot               `json:"open_lots"`
+	Unmatched    []*Lot               `json:"unmatched"`
+	Realised     float64              `json:"realised"`
+	Turnover     float64              `json:"turnover"`
+	Charges      float64              `json:"charges"`
+}
+
+// Report builds the FIFO realised P&L of the journal between from and to
+func (j *Journal) Report(from string, to string) (*Report, error) {
+	entries, err := j.LoadRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return BuildReport(entries), nil
+}
+
+// BuildReport matches the fills of the entries first in, first out per
+// instrument. Entries must be in date order.
+func BuildReport(entries []*Entry) *Report {
+	report := &Report{
+		Realisations: []*Realisation{},
+		Instruments:  []*InstrumentSummary{},
+		OpenLots:     []*Lot{},
+		Unmatched:    []*Lot{},
+	}
+	if len(entries) > 0 {
+		report.From = entries[0].Date
+		report.To = entries[len(entries)-1].Date
+	}
+
+	type fill struct {
+		trade *kite.Trade
+		date  string
+	}
+	fills := []*fill{}
+	seen := map[string]bool{}
+	summaries := map[string]*InstrumentSummary{}
+	summary := func(exchange string, tradingSymbol string) *InstrumentSummary {
+		key := exchange + ":" + tradingSymbol
+		s, ok := summaries[key]
+		if !ok {
+			s = &InstrumentSummary{Exchange: exchange, TradingSymbol: tradingSymbol}
+			summaries[key] = s
+		}
+		return s
+	}
+
+	for _, entry := range entries {
+		for _, trade := range entry.Trades {
+			if trade.TradeId != "" && seen[trade.TradeId] {
+				continue
+			}
+			seen[trade.TradeId] = true
+			date := entry.Date
+			if len(trade.FillTimestamp) >= len(dateFormat) {
+				date = trade.FillTimestamp[:len(dateFormat)]
+			}
+			fills = append(fills, &fill{trade: trade, date: date})
+		}
+		for _, c := range entry.Charges {
+			if c.Charges == nil {
+				continue
+			}
+			summary(c.Exchange, c.TradingSymbol).Charges += c.Charges.Total
+			report.Charges += c.Charges.Total
+		}
+	}
+	sort.SliceStable(fills, func(i, k int) bool {
+		a, b := fills[i], fills[k]
+		if a.trade.FillTimestamp != b.trade.FillTimestamp {
+			return a.trade.FillTimestamp < b.trade.FillTimestamp
+		}
+		return a.trade.TradeId < b.trade.TradeId
+	})
+
+	lots := map[string][]*Lot{}
+	keys := []string{}
+	for _, f := range fills {
+		trade := f.trade
+		key := trade.Exchange + ":" + trade.TradingSymbol
+		if _, ok := lots[key]; !ok {
+			keys = append(keys, key)
+		}
+		s := summary(trade.Exchange, trade.TradingSymbol)
+		multiplier := kite.QuantityMultiplier(trade.Exchange, trade.TradingSymbol)
+		value := float64(trade.Quantity) * multiplier * trade.AveragePrice
+		s.Turnover += value
+		report.Turnover += value
+
+		quantity := float64(trade.Quantity)
+		if trade.TransactionType == "SELL" {
+			quantity = -quantity
+		}
+		open := lots[key]
+		for quantity != 0 && len(open) > 0 && (open[0].Quantity > 0) != (quantity > 0) {
+			lot := open[0]
+			matched := math.Min(math.Abs(quantity), math.Abs(lot.Quantity))
+			r := &Realisation{
+				Exchange:      trade.Exchange,
+				TradingSymbol: trade.TradingSymbol,
+				Quantity:      matched,
+			}
+			if lot.Quantity > 0 {
+				r.BuyDate, r.BuyPrice = lot.Date, lot.Price
+				r.SellDate, r.SellPrice = f.date, trade.AveragePrice
+			} else {
+				r.SellDate, r.SellPrice = lot.Date, lot.Price
+				r.BuyDate, r.BuyPrice = f.date, trade.AveragePrice
+			}
+			r.Pnl = matched * multiplier * (r.SellPrice - r.BuyPrice)
+			r.HoldingDays = holdingDays(lot.Date, f.date)
+			r.Classification = classify(trade.Exchange, lot.Date, f.date, r.HoldingDays)
+			report.Realisations = append(report.Realisations, r)
+			report.Realised += r.Pnl
+			s.Realised += r.Pnl
+			switch r.Classification {
+			case Intraday:
+				s.Intraday += r.Pnl
+			case STCG:
+				s.STCG += r.Pnl
+			case LTCG:
+				s.LTCG += r.Pnl
+			case FNO:
+				s.FNO += r.Pnl
+			}
+
+			if lot.Quantity > 0 {
+				lot.Quantity -= matched
+				quantity += matched
+			} else {
+				lot.Quantity += matched
+				quantity -= matched
+			}
+			if lot.Quantity == 0 {
+				open = open[1:]
+			}
+		}
+		// Delivery equity cannot be sold short, so a sell left over is a
+		// disposal of shares bought before the journal
+		if quantity < 0 && trade.Product == "CNC" {
+			report.Unmatched = append(report.Unmatched, &Lot{
+				Exchange:      trade.Exchange,
+				TradingSymbol: trade.TradingSymbol,
+				Date:          f.date,
+				Quantity:      quantity,
+				Price:         trade.AveragePrice,
+			})
+			quantity = 0
+		}
+		if quantity != 0 {
+			open = append(open, &Lot{
+				Exchange:      trade.Exchange,
+				TradingSymbol: trade.TradingSymbol,
+				Date:          f.date,
+				Quantity:      quantity,
+				Price:         trade.AveragePrice,
+			})
+		}
+		lots[key] = open
+	}
+
+	for _, key := range keys {
+		report.OpenLots = append(report.OpenLots, lots[key]...)
+	}
+	for _, s := range summaries {
+		report.Instruments = append(report.Instruments, s)
+	}
+	sort.Slice(report.Instruments, func(i, k int) bool {
+		a, b := report.Instruments[i], report.Instruments[k]
+		if a.Exchange != b.Exchange {
+			return a.Exchange < b.Exchange
+		}
+		return a.TradingSymbol < b.TradingSymbol
+	})
+	return report
+}
+
+// WriteJSON writes the whole report as JSON
+func (report *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV writes one row per FIFO match
+func (report *Report) WriteCSV(w io.Writer) error {
+	return gocsv.Marshal(report.Realisations, w)
+}
+
+// WriteSummaryCSV writes one row per instrument
+func (report *Report) WriteSummaryCSV(w io.Writer) error {
+	return gocsv.Marshal(report.Instruments, w)
+}
+
+func holdingDays(opened string, closed string) int {
+	openDate, err := time.Parse(dateFormat, opened)
+	if err != nil {
+		return 0
+	}
+	closeDate, err := time.Parse(dateFormat, closed)
+	if err != nil {
+		return 0
+	}
+	return int(closeDate.Sub(openDate).Hours() / 24)
+}
+
+func classify(exchange string, opened string, closed string, days int) string {
+	switch exchange {
+	case "NFO", "BFO", "MCX", "CDS", "BCD":
+		return FNO
+	}
+	if opened == closed {
+		return Intraday
+	}
+	if days > LongTermDays {
+		return LTCG
+	}
+	return STCG
+}
@@ -43,3 +43,47 @@ func (packet Packet) ParseMarketDepth() []uint32 {
 		}
 	}
 }
+
+// Full packets carry a 64 byte quote followed by the buy levels and then the
+// sell levels of the market depth, 12 bytes each: quantity, price, orders
+// and 2 bytes of padding. Regular full packets carry 5 levels a side and
+// 20 depth packets 20.
+const (
+	QuotePacketSize = 64
+	DepthEntrySize  = 12
+	DepthLevels     = 5
+	MaxDepthLevels  = 20
+)
+
+// DepthLevels returns the number of levels a side in the full packet
+func (packet Packet) DepthLevels() int {
+	if len(packet) <= QuotePacketSize {
+		return 0
+	}
+	levels := (len(packet) - QuotePacketSize) / (2 * DepthEntrySize)
+	if levels > MaxDepthLevels {
+		levels = MaxDepthLevels
+	}
+	return levels
+}
+
+// ParseDepth parses the market depth of a full packet, of either 5 or 20
+// levels, dividing prices by divisor
+func (packet Packet) ParseDepth(divisor float64) Depth {
+	depth := Depth{}
+	levels := packet.DepthLevels()
+	for i := 0; i < 2*levels; i++ {
+		entry := packet[QuotePacketSize+i*DepthEntrySize:]
+		order := LimitOrder{
+			Quantity: binary.BigEndian.Uint32(entry[0:4]),
+			Price:    float64(binary.BigEndian.Uint32(entry[4:8])) / divisor,
+			Orders:   uint32(binary.BigEndian.Uint16(entry[8:10])),
+		}
+		if i < levels {
+			depth.Buy = append(depth.Buy, order)
+		} else {
+			depth.Sell = append(depth.Sell, order)
+		}
+	}
+	return depth
+}
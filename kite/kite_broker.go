@@ -0,0 +1,75 @@
+package kite
+
+import (
+	"context"
+	"time"
+)
+
+// OrderManager places, amends and tracks orders
+type OrderManager interface {
+	PlaceOrder(ctx *context.Context, order *Order) (string, error)
+	ModifyOrder(ctx *context.Context, orderId string, order *Order) error
+	CancelOrder(ctx *context.Context, orderId string) error
+	GetOrders(ctx *context.Context) ([]*OrderStatus, error)
+	GetOrderHistory(ctx *context.Context, orderId string) ([]*OrderStatus, error)
+	GetTrades(ctx *context.Context) ([]*Trade, error)
+	GetOrderTrades(ctx *context.Context, orderId string) ([]*Trade, error)
+	SetPreTradeCheck(check func(order *Order) error)
+}
+
+// Portfolio reports the account, its positions, holdings, margin and charges
+type Portfolio interface {
+	GetProfile(ctx *context.Context) (*Profile, error)
+	GetPositions(ctx *context.Context) (*Positions, error)
+	ConvertPosition(ctx *context.Context, position *Position, oldProduct string, newProduct string, quantity uint32) error
+	GetHoldings(ctx *context.Context) ([]*Holding, error)
+	GetMargin(ctx *context.Context) (*Margin, error)
+	GetSegmentMargin(ctx *context.Context, segment string) (*SegmentMargin, error)
+	GetCharges(ctx *context.Context) (float64, error)
+	GetOrderCharges(ctx *context.Context) ([]*BrokerCharges, error)
+}
+
+// MarketData serves instruments, quotes and historical candles
+type MarketData interface {
+	FetchInstruments() (Instruments, error)
+	GetQuote(ctx *context.Context, exchange string, tradingSymbol string) (*Quote, error)
+	GetLastPrice(ctx *context.Context, exchange string, tradingSymbol string) (float64, error)
+	GetQuotes(ctx *context.Context, keys []string) (map[string]*Quote, error)
+	GetOHLC(ctx *context.Context, keys []string) (map[string]*OHLCQuote, error)
+	GetLTP(ctx *context.Context, keys []string) (map[string]*LTPQuote, error)
+	GetHistoricalData(ctx *context.Context, exchange string, tradingSymbol string, interval Interval, from time.Time, to time.Time, continuous bool) ([]*Candle, error)
+}
+
+// Streamer opens websocket tickers and caches the ticks they deliver
+type Streamer interface {
+	GetWebSocketClient(ctx *context.Context) (*TickerClient, error)
+	AddTickerClient(ticker *TickerClient)
+	StoreTick(ticker KiteTicker)
+}
+
+// Broker is everything a strategy needs from a broker. *Kite is the live
+// implementation, paper.Broker simulates orders against the live feed.
+type Broker interface {
+	OrderManager
+	Portfolio
+	MarketData
+	Streamer
+}
+
+var _ Broker = (*Kite)(nil)
+
+// SetPreTradeCheck installs a check that every order must pass before it is
+// placed or modified
+func (kite *Kite) SetPreTradeCheck(check func(order *Order) error) {
+	kite.PreTradeCheck = check
+}
+
+// AddTickerClient registers a websocket ticker whose ticks feed TickSymbolMap
+func (kite *Kite) AddTickerClient(ticker *TickerClient) {
+	kite.TickSymbolMapMutex.Lock()
+	if kite.TickSymbolMap == nil {
+		kite.TickSymbolMap = map[string]KiteTicker{}
+	}
+	kite.TickSymbolMapMutex.Unlock()
+	kite.TickerClients = append(kite.TickerClients, ticker)
+}
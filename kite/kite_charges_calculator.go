@@ -0,0 +1,175 @@
+package kite
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strings"
+)
+
+// FeeTable holds the rates applied to one segment. Rates are fractions of
+// turnover, e.g. 0.001 for 0.1%.
+type FeeTable struct {
+	Brokerage          float64 `json:"brokerage"`
+	BrokerageRate      float64 `json:"brokerage_rate"`
+	BrokerageCap       float64 `json:"brokerage_cap"`
+	TransactionTaxBuy  float64 `json:"transaction_tax_buy"`
+	TransactionTaxSell float64 `json:"transaction_tax_sell"`
+	TransactionTaxType string  `json:"transaction_tax_type"`
+	ExchangeTurnover   float64 `json:"exchange_turnover"`
+	SebiTurnover       float64 `json:"sebi_turnover"`
+	StampDutyBuy       float64 `json:"stamp_duty_buy"`
+	GST                float64 `json:"gst"`
+}
+
+// FeeTables maps a segment key to its rates. Keys are the exchange followed
+// by EQ_DELIVERY, EQ_INTRADAY, FUT or OPT, e.g. "NSE:EQ_DELIVERY", "NFO:OPT".
+type FeeTables map[string]*FeeTable
+
+// DefaultFeeTables are the discount broker rates for NSE, BSE, NFO, BFO and
+// MCX
+var DefaultFeeTables = FeeTables{
+	"NSE:EQ_DELIVERY": {TransactionTaxBuy: 0.001, TransactionTaxSell: 0.001, TransactionTaxType: "stt", ExchangeTurnover: 0.0000297, SebiTurnover: 0.000001, StampDutyBuy: 0.00015, GST: 0.18},
+	"NSE:EQ_INTRADAY": {BrokerageRate: 0.0003, BrokerageCap: 20, TransactionTaxSell: 0.00025, TransactionTaxType: "stt", ExchangeTurnover: 0.0000297, SebiTurnover: 0.000001, StampDutyBuy: 0.00003, GST: 0.18},
+	"BSE:EQ_DELIVERY": {TransactionTaxBuy: 0.001, TransactionTaxSell: 0.001, TransactionTaxType: "stt", ExchangeTurnover: 0.0000375, SebiTurnover: 0.000001, StampDutyBuy: 0.00015, GST: 0.18},
+	"BSE:EQ_INTRADAY": {BrokerageRate: 0.0003, BrokerageCap: 20, TransactionTaxSell: 0.00025, TransactionTaxType: "stt", ExchangeTurnover: 0.0000375, SebiTurnover: 0.000001, StampDutyBuy: 0.00003, GST: 0.18},
+	"NFO:FUT":         {BrokerageRate: 0.0003, BrokerageCap: 20, TransactionTaxSell: 0.0002, TransactionTaxType: "stt", ExchangeTurnover: 0.0000173, SebiTurnover: 0.000001, StampDutyBuy: 0.00002, GST: 0.18},
+	"NFO:OPT":         {Brokerage: 20, TransactionTaxSell: 0.001, TransactionTaxType: "stt", ExchangeTurnover: 0.0003503, SebiTurnover: 0.000001, StampDutyBuy: 0.00003, GST: 0.18},
+	"BFO:FUT":         {BrokerageRate: 0.0003, BrokerageCap: 20, TransactionTaxSell: 0.0002, TransactionTaxType: "stt", SebiTurnover: 0.000001, StampDutyBuy: 0.00002, GST: 0.18},
+	"BFO:OPT":         {Brokerage: 20, TransactionTaxSell: 0.001, TransactionTaxType: "stt", ExchangeTurnover: 0.000325, SebiTurnover: 0.000001, StampDutyBuy: 0.00003, GST: 0.18},
+	"MCX:FUT":         {BrokerageRate: 0.0003, BrokerageCap: 20, TransactionTaxSell: 0.0001, TransactionTaxType: "ctt", ExchangeTurnover: 0.000021, SebiTurnover: 0.000001, StampDutyBuy: 0.00002, GST: 0.18},
+	"MCX:OPT":         {Brokerage: 20, TransactionTaxSell: 0.0005, TransactionTaxType: "ctt", ExchangeTurnover: 0.000418, SebiTurnover: 0.000001, StampDutyBuy: 0.00003, GST: 0.18},
+}
+
+// LoadFeeTables reads fee tables from a JSON file shaped like
+// DefaultFeeTables. Segments missing from the file keep the default rates.
+func LoadFeeTables(path string) (FeeTables, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loaded := FeeTables{}
+	err = json.Unmarshal(data, &loaded)
+	if err != nil {
+		return nil, err
+	}
+	tables := FeeTables{}
+	for key, table := range DefaultFeeTables {
+		tables[key] = table
+	}
+	for key, table := range loaded {
+		tables[key] = table
+	}
+	return tables, nil
+}
+
+// ChargesCalculator estimates order charges offline from fee tables, for
+// backtests and paper trading
+type ChargesCalculator struct {
+	FeeTables FeeTables
+}
+
+func NewChargesCalculator(tables FeeTables) *ChargesCalculator {
+	if tables == nil {
+		tables = DefaultFeeTables
+	}
+	return &ChargesCalculator{FeeTables: tables}
+}
+
+// Calculate returns the charges of a filled order in the same shape as
+// GetOrderCharges
+func (c *ChargesCalculator) Calculate(order *ChargesOrderRequest) (*BrokerCharges, error) {
+	key := order.Exchange + ":" + chargesSegment(order.Exchange, order.TradingSymbol, order.Product)
+	table, ok := c.FeeTables[key]
+	if !ok {
+		return nil, errors.New("fee_table_not_found:" + key)
+	}
+
+	turnover := float64(order.Quantity) * QuantityMultiplier(order.Exchange, order.TradingSymbol) * order.AveragePrice
+	isBuy := order.TransactionType == "BUY"
+
+	brokerage := table.Brokerage
+	if table.BrokerageRate > 0 {
+		brokerage = turnover * table.BrokerageRate
+		if table.BrokerageCap > 0 {
+			brokerage = math.Min(brokerage, table.BrokerageCap)
+		}
+	}
+	transactionTax := turnover * table.TransactionTaxSell
+	stampDuty := 0.0
+	if isBuy {
+		transactionTax = turnover * table.TransactionTaxBuy
+		stampDuty = turnover * table.StampDutyBuy
+	}
+	exchangeCharge := turnover * table.ExchangeTurnover
+	sebiCharge := turnover * table.SebiTurnover
+	gst := (brokerage + exchangeCharge + sebiCharge) * table.GST
+
+	return &BrokerCharges{
+		OrderId:         order.OrderId,
+		TransactionType: order.TransactionType,
+		TradingSymbol:   order.TradingSymbol,
+		Exchange:        order.Exchange,
+		Variety:         order.Variety,
+		Product:         order.Product,
+		OrderType:       order.OrderType,
+		Quantity:        order.Quantity,
+		Price:           order.AveragePrice,
+		Charges: &ChargesBreakdown{
+			TransactionTax:         transactionTax,
+			TransactionTaxType:     table.TransactionTaxType,
+			ExchangeTurnoverCharge: exchangeCharge,
+			SebiTurnoverCharge:     sebiCharge,
+			Brokerage:              brokerage,
+			StampDuty:              stampDuty,
+			GST:                    &GST{IGST: gst, Total: gst},
+			Total:                  brokerage + transactionTax + exchangeCharge + sebiCharge + gst + stampDuty,
+		},
+	}, nil
+}
+
+// QuantityMultiplier is the number of units in one unit of quantity. MCX
+// quantities are in lots, so it is the lot size of the instrument there and 1
+// elsewhere or when the instrument is not loaded.
+func QuantityMultiplier(exchange string, tradingSymbol string) float64 {
+	if exchange != "MCX" || BrokerInstrumentTokens == nil {
+		return 1
+	}
+	instrument, ok := (*BrokerInstrumentTokens)[exchange+":"+tradingSymbol]
+	if !ok || instrument.LotSize <= 0 {
+		return 1
+	}
+	return instrument.LotSize
+}
+
+// chargesSegment classifies an instrument as EQ_DELIVERY, EQ_INTRADAY, FUT or
+// OPT using the instrument master, falling back to the symbol suffix on
+// derivative exchanges
+func chargesSegment(exchange string, tradingSymbol string, product string) string {
+	instrumentType := ""
+	if BrokerInstrumentTokens != nil {
+		if instrument, ok := (*BrokerInstrumentTokens)[exchange+":"+tradingSymbol]; ok {
+			instrumentType = instrument.InstrumentType
+		}
+	}
+	if instrumentType == "" && exchange != "NSE" && exchange != "BSE" {
+		switch {
+		case strings.HasSuffix(tradingSymbol, "FUT"):
+			instrumentType = "FUT"
+		case strings.HasSuffix(tradingSymbol, "CE"), strings.HasSuffix(tradingSymbol, "PE"):
+			instrumentType = "CE"
+		}
+	}
+
+	switch instrumentType {
+	case "FUT":
+		return "FUT"
+	case "CE", "PE":
+		return "OPT"
+	}
+	if product == "CNC" || product == "MTF" {
+		return "EQ_DELIVERY"
+	}
+	return "EQ_INTRADAY"
+}
@@ -0,0 +1,135 @@
+package kite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/requests"
+)
+
+type ConvertPositionPayload struct {
+	Exchange        string `query:"exchange"`
+	TradingSymbol   string `query:"tradingsymbol"`
+	TransactionType string `query:"transaction_type"`
+	PositionType    string `query:"position_type"`
+	Quantity        string `query:"quantity"`
+	OldProduct      string `query:"old_product"`
+	NewProduct      string `query:"new_product"`
+}
+
+type ConvertPositionResponsePayload struct {
+	Status    string `json:"error"`
+	Message   string `json:"message"`
+	ErrorType string `json:"error_type"`
+	Data      bool   `json:"data"`
+}
+
+// ConvertPosition moves quantity of an open position from oldProduct to
+// newProduct, e.g. MIS to NRML/CNC to carry it overnight. The quantity is
+// checked against the current net position before the request is sent.
+func (kite *Kite) ConvertPosition(ctx *context.Context, position *Position, oldProduct string, newProduct string, quantity uint32) error {
+	k := *(*kite).Creds
+
+	data, err := kite.fetchPositions(ctx)
+	if err != nil {
+		return err
+	}
+	var current *Position
+	for _, net := range data.Net {
+		if net.Exchange == position.Exchange && net.TradingSymbol == position.TradingSymbol && net.Product == oldProduct {
+			current = net
+			break
+		}
+	}
+	if current == nil {
+		return errors.New("position_not_found")
+	}
+	err = ValidateConversion(current, oldProduct, newProduct, quantity)
+	if err != nil {
+		return err
+	}
+
+	kPayload := &ConvertPositionPayload{
+		Exchange:        current.Exchange,
+		TradingSymbol:   current.TradingSymbol,
+		TransactionType: "BUY",
+		PositionType:    "day",
+		Quantity:        fmt.Sprintf("%v", quantity),
+		OldProduct:      oldProduct,
+		NewProduct:      newProduct,
+	}
+	if current.Quantity < 0 {
+		kPayload.TransactionType = "SELL"
+	}
+	dayQuantity := current.DayBuyQuantity - current.DaySellQuantity
+	if dayQuantity == 0 || (dayQuantity > 0) != (current.Quantity > 0) {
+		kPayload.PositionType = "overnight"
+	}
+
+	log.Infof("Converting the following position : %+v", kPayload)
+
+	url := k["Url"] + "/portfolio/positions"
+	queries := make([]string, 0)
+	typ := reflect.TypeOf(*kPayload)
+	val := reflect.ValueOf(kPayload).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		fieldName := val.Type().Field(i).Name
+		ft, _ := typ.FieldByName(fieldName)
+		fv := val.FieldByName(fieldName)
+		queries = append(queries, fmt.Sprintf("%v=%v", ft.Tag.Get("query"), fv))
+	}
+	payload := strings.Join(queries, "&")
+	headers := make(map[string]string)
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
+	response, code, err := requests.Put(ctx, url, payload, headers)
+	if err != nil {
+		return err
+	}
+
+	var respData *ConvertPositionResponsePayload
+	err = json.Unmarshal(response, &respData)
+	if err != nil {
+		return err
+	}
+	if code == 200 && respData.Data {
+		return nil
+	}
+	return errors.New(respData.Status + ":" + respData.Message)
+}
+
+// ValidateConversion checks a conversion request against the current net
+// position it applies to
+func ValidateConversion(current *Position, oldProduct string, newProduct string, quantity uint32) error {
+	for _, product := range []string{oldProduct, newProduct} {
+		if product != "MIS" && product != "NRML" && product != "CNC" {
+			return errors.New("product_not_allowed")
+		}
+	}
+	if oldProduct == newProduct {
+		return errors.New("products_must_differ")
+	}
+	if current.Product != oldProduct {
+		return errors.New("position_product_mismatch")
+	}
+	if quantity == 0 {
+		return errors.New("quantity_must_be_positive")
+	}
+	if current.Quantity == 0 {
+		return errors.New("position_not_open")
+	}
+	net := current.Quantity
+	if net < 0 {
+		net = -net
+	}
+	if int64(quantity) > net {
+		return errors.New("quantity_exceeds_position")
+	}
+	return nil
+}
@@ -3,12 +3,30 @@ package kite
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/souvik131/kite-go-library/requests"
 )
 
+// GetCharges returns the total charges of all completed orders of the day
 func (kite *Kite) GetCharges(ctx *context.Context) (float64, error) {
+	orderCharges, err := kite.GetOrderCharges(ctx)
+	if err != nil {
+		return 0.0, err
+	}
+	charges := 0.0
+	for _, c := range orderCharges {
+		if c.Charges != nil {
+			charges += c.Charges.Total
+		}
+	}
+	return charges, nil
+}
+
+// GetOrderCharges returns the brokerage, STT/CTT, exchange turnover, SEBI,
+// GST and stamp duty of every completed order of the day
+func (kite *Kite) GetOrderCharges(ctx *context.Context) ([]*BrokerCharges, error) {
 
 	k := *(*kite).Creds
 	url := k["Url"] + "/orders"
@@ -26,66 +44,78 @@ func (kite *Kite) GetCharges(ctx *context.Context) (float64, error) {
 	res, code, cookie, err := requests.GetWithCookies(ctx, url, headers, k["Cookie"])
 	k["Cookie"] = cookie
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
 
 	var respData *OrdersResponsePayload
 	err = json.Unmarshal(res, &respData)
 	if err != nil {
-		return 0.0, err
+		return nil, err
+	}
+	if code != 200 || respData.Data == nil {
+		return nil, fmt.Errorf("%v:%v", respData.Status, respData.Message)
 	}
-	if code == 200 && respData.Data != nil {
-		requestOrders := make([]*ChargesOrderRequest, 0)
 
-		for _, order := range respData.Data {
-			if order.OrderState == "COMPLETE" {
-				requestOrders = append(requestOrders, &ChargesOrderRequest{
-					AveragePrice:    order.AveragePrice,
-					Exchange:        order.Exchange,
-					OrderId:         order.OrderId,
-					Product:         order.Product,
-					Quantity:        order.Quantity,
-					TradingSymbol:   order.TradingSymbol,
-					Variety:         order.Variety,
-					OrderType:       order.OrderType,
-					TransactionType: order.TransactionType,
-				})
-			}
+	requestOrders := make([]*ChargesOrderRequest, 0)
+	for _, order := range respData.Data {
+		if order.OrderState == "COMPLETE" {
+			requestOrders = append(requestOrders, ChargesRequestFromOrder(order))
 		}
+	}
+	if len(requestOrders) == 0 {
+		return []*BrokerCharges{}, nil
+	}
+	return kite.GetChargesForOrders(ctx, requestOrders)
+}
 
-		url := k["Url"] + "/charges/orders"
-
-		bytes, err := json.Marshal(requestOrders)
-		if err != nil {
-			return 0.0, err
-		}
-		payload := string(bytes)
-		headers := make(map[string]string)
-		headers["authorization"] = k["Token"]
-		headers["content-type"] = "application/json"
+// GetChargesForOrders asks the broker for the charges of the given orders
+func (kite *Kite) GetChargesForOrders(ctx *context.Context, requestOrders []*ChargesOrderRequest) ([]*BrokerCharges, error) {
+	k := *(*kite).Creds
+	url := k["Url"] + "/charges/orders"
 
-		res, code, cookie, err := requests.PostWithCookies(ctx, url, payload, headers, k["Cookie"])
-		k["Cookie"] = cookie
-		if err != nil {
-			return 0.0, err
-		}
+	bytes, err := json.Marshal(requestOrders)
+	if err != nil {
+		return nil, err
+	}
+	payload := string(bytes)
+	headers := make(map[string]string)
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/json"
 
-		var respData *BrokerChargesPayload
-		err = json.Unmarshal(res, &respData)
-		if err != nil {
-			return 0.0, err
-		}
+	res, code, cookie, err := requests.PostWithCookies(ctx, url, payload, headers, k["Cookie"])
+	k["Cookie"] = cookie
+	if err != nil {
+		return nil, err
+	}
 
-		if code == 200 && respData.Data != nil {
-			charges := 0.0
-			for _, c := range respData.Data {
-				charges += c.Charges.Total
+	var respData *BrokerChargesPayload
+	err = json.Unmarshal(res, &respData)
+	if err != nil {
+		return nil, err
+	}
+	if code == 200 && respData.Data != nil {
+		// The response is in request order but does not echo the order id
+		for i, c := range respData.Data {
+			if i < len(requestOrders) && c.OrderId == "" {
+				c.OrderId = requestOrders[i].OrderId
 			}
-			return charges, nil
 		}
-		return 0.0, nil
-
+		return respData.Data, nil
 	}
-	return 0.0, fmt.Errorf("%v", respData.Data)
+	return nil, errors.New(respData.Status + ":" + respData.Message)
+}
 
+// ChargesRequestFromOrder builds the /charges/orders request of an order
+func ChargesRequestFromOrder(order *OrderStatus) *ChargesOrderRequest {
+	return &ChargesOrderRequest{
+		AveragePrice:    order.AveragePrice,
+		Exchange:        order.Exchange,
+		OrderId:         order.OrderId,
+		Product:         order.Product,
+		Quantity:        order.FilledQuantity,
+		TradingSymbol:   order.TradingSymbol,
+		Variety:         order.Variety,
+		OrderType:       order.OrderType,
+		TransactionType: order.TransactionType,
+	}
 }
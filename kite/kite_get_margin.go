@@ -4,42 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 
 	"github.com/souvik131/kite-go-library/requests"
 )
 
+// GetMargin returns the equity and commodity margins of the account
 func (kite *Kite) GetMargin(ctx *context.Context) (*Margin, error) {
 	k := *(*kite).Creds
 	url := k["Url"] + "/user/margins"
 
-	headers := map[string]string{
-		"Connection":      "keep-alive",
-		"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
-		"Accept-Encoding": "gzip, deflate",
-		"Host":            "kite.zerodha.com",
-		"Accept":          "*/*",
+	res, code, err := kite.getMargin(ctx, url)
+	if err != nil {
+		return nil, err
 	}
-	headers["authorization"] = k["Token"]
-	headers["content-type"] = "application/x-www-form-urlencoded"
+	var respData *MarginResponsePayload
+	err = json.Unmarshal(res, &respData)
+	if err != nil {
+		return nil, err
+	}
+	if code == 200 && respData.Data != nil {
+		margin := respData.Data
+		if margin.Equity != nil && margin.Equity.Utilised != nil {
+			margin.MarginUsed = margin.Equity.Utilised.Debits
+			margin.MarginTotal = margin.Equity.Net + margin.Equity.Utilised.Debits
+		}
+		return margin, nil
+	}
+	return nil, errors.New(respData.Status + ":" + respData.Message)
+}
 
-	res, code, cookie, err := requests.GetWithCookies(ctx, url, headers, k["Cookie"])
-	k["Cookie"] = cookie
+// GetSegmentMargin returns the margin of a single segment, equity or commodity
+func (kite *Kite) GetSegmentMargin(ctx *context.Context, segment string) (*SegmentMargin, error) {
+	if segment != "equity" && segment != "commodity" {
+		return nil, errors.New("segment_not_allowed")
+	}
+	k := *(*kite).Creds
+	url := k["Url"] + "/user/margins/" + segment
 
+	res, code, err := kite.getMargin(ctx, url)
 	if err != nil {
 		return nil, err
 	}
-	var respData *MarginResponsePayload
+	var respData *SegmentMarginResponsePayload
 	err = json.Unmarshal(res, &respData)
 	if err != nil {
 		return nil, err
 	}
 	if code == 200 && respData.Data != nil {
-		log.Printf("%v", respData.Data)
-		return &Margin{
-			MarginUsed:  respData.Data.Equity.Utilised.Debits,
-			MarginTotal: respData.Data.Equity.Net + respData.Data.Equity.Utilised.Debits,
-		}, nil
+		return respData.Data, nil
 	}
 	return nil, errors.New(respData.Status + ":" + respData.Message)
 }
+
+func (kite *Kite) getMargin(ctx *context.Context, url string) ([]byte, int, error) {
+	k := *(*kite).Creds
+
+	headers := map[string]string{
+		"Connection":      "keep-alive",
+		"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		"Accept-Encoding": "gzip, deflate",
+		"Host":            "kite.zerodha.com",
+		"Accept":          "*/*",
+	}
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
+	res, code, cookie, err := requests.GetWithCookies(ctx, url, headers, k["Cookie"])
+	k["Cookie"] = cookie
+	return res, code, err
+}
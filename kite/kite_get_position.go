@@ -4,13 +4,49 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"math"
 
 	"github.com/souvik131/kite-go-library/requests"
 )
 
-func (kiteClient *Kite) GetPositions(ctx *context.Context) error {
+type Positions struct {
+	Net []*Position `json:"net"`
+	Day []*Position `json:"day"`
+	Pnl float64     `json:"pnl"`
+}
+
+// GetPositions returns the net and day positions marked to the latest price.
+// Prices come from fresh websocket ticks, then batched LTP calls. A stale tick
+// or, failing that, the close price is used with PriceFallback set.
+func (kiteClient *Kite) GetPositions(ctx *context.Context) (*Positions, error) {
+	data, err := kiteClient.fetchPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, position := range append(data.Net, data.Day...) {
+		keys = append(keys, position.Exchange+":"+position.TradingSymbol)
+	}
+	prices, stale := kiteClient.getLastPrices(ctx, keys)
+
+	positions := &Positions{Net: data.Net, Day: data.Day}
+	for _, position := range append(data.Net, data.Day...) {
+		lastPrice, ok := prices[position.Exchange+":"+position.TradingSymbol]
+		position.PriceFallback = !ok || stale[position.Exchange+":"+position.TradingSymbol]
+		if !ok {
+			lastPrice = position.ClosePrice
+		}
+		position.LastPrice = lastPrice
+		position.Pnl = position.MTM(lastPrice)
+	}
+	for _, position := range positions.Net {
+		positions.Pnl += position.Pnl
+	}
+	return positions, nil
+}
+
+func (kiteClient *Kite) fetchPositions(ctx *context.Context) (*PositionsData, error) {
 
 	k := *(*kiteClient).Creds
 	url := k["Url"] + "/portfolio/positions"
@@ -28,54 +64,71 @@ func (kiteClient *Kite) GetPositions(ctx *context.Context) error {
 	res, code, cookie, err := requests.GetWithCookies(ctx, url, headers, k["Cookie"])
 	k["Cookie"] = cookie
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var respData *PositionResponsePayload
 	err = json.Unmarshal(res, &respData)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if respData == nil {
-		return errors.New("kite_broker_api_issue")
+		return nil, errors.New("kite_broker_api_issue")
 	}
 
 	if code == 200 && respData.Data != nil {
-		positions := []*Position{}
-
-		priceMap := map[string]float64{}
-
-		for _, net := range respData.Data.Net {
-			lp, err := kiteClient.GetLastPrice(ctx, net.Exchange, net.TradingSymbol)
-			if err != nil {
-				log.Panic(net.TradingSymbol, err)
-			} else {
-
-				net.LastPrice = lp
-				priceMap[net.TradingSymbol] = lp
-			}
-
-			positions = append(positions, net)
+		return respData.Data, nil
+	}
+	return nil, errors.New(respData.Status + ":" + respData.Message)
+}
 
+// getLastPrices looks the keys ("EXCHANGE:SYMBOL") up in the websocket feed
+// and fetches those without a fresh tick with batched LTP calls for API
+// logins. The second result marks keys priced from a stale tick. Symbols
+// without any price are left out.
+func (kiteClient *Kite) getLastPrices(ctx *context.Context, keys []string) (map[string]float64, map[string]bool) {
+	prices := map[string]float64{}
+	stale := map[string]bool{}
+	missing := []string{}
+	policy := kiteClient.GetQuotePolicy()
+
+	kiteClient.TickSymbolMapMutex.RLock()
+	for _, key := range keys {
+		if _, ok := prices[key]; ok {
+			continue
 		}
-
-		kiteClient.Positions = positions
-		pnl := 0.0
-		for _, net := range positions {
-			if lastPrice, ok := priceMap[net.TradingSymbol]; ok {
-				if net.Quantity == 0 {
-					pnl += net.SellValue - net.BuyValue
-				} else {
-					pnl += net.SellValue + float64(net.Quantity)*math.Abs(lastPrice*float64(net.Multiplier)) - net.BuyValue
-				}
-			} else {
-				log.Fatal("price not present", net.TradingSymbol)
+		if ticker, ok := kiteClient.TickSymbolMap[key]; ok && ticker.LastPrice > 0 {
+			prices[key] = ticker.LastPrice
+			if policy.Fresh(ticker) {
+				continue
 			}
+			stale[key] = true
 		}
-		kiteClient.Pnl = pnl
+		missing = append(missing, key)
+	}
+	kiteClient.TickSymbolMapMutex.RUnlock()
 
-		return nil
+	if len(missing) == 0 || (*kiteClient.Creds)["LoginType"] == "WEB" {
+		return prices, stale
 	}
-	return errors.New(respData.Status + ":" + respData.Message)
 
+	quotes, err := kiteClient.GetLTP(ctx, missing)
+	if err != nil {
+		return prices, stale
+	}
+	for key, quote := range quotes {
+		if quote != nil && quote.LastPrice > 0 {
+			prices[key] = quote.LastPrice
+			delete(stale, key)
+		}
+	}
+	return prices, stale
+}
+
+// MTM returns the mark-to-market of the position valued at lastPrice.
+func (position *Position) MTM(lastPrice float64) float64 {
+	if position.Quantity == 0 {
+		return position.SellValue - position.BuyValue
+	}
+	return position.SellValue + float64(position.Quantity)*math.Abs(lastPrice*float64(position.Multiplier)) - position.BuyValue
 }
@@ -8,65 +8,108 @@ import (
 	"net/url"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
 	"github.com/souvik131/kite-go-library/requests"
 )
 
+// IST is the exchange time zone
+var IST = clock.IST
+
+// QuoteTimeFormat is the timestamp layout of quote responses
+const QuoteTimeFormat = "2006-01-02 15:04:05"
+
+// QuoteFromTicker converts a websocket tick into the REST quote schema so
+// callers see the same fields whatever the source
+func QuoteFromTicker(ticker KiteTicker) *Quote {
+	quote := &Quote{
+		InstrumentToken: ticker.Token,
+		LastPrice:       ticker.LastPrice,
+		LastQuantity:    ticker.LastTradedQuantity,
+		BuyQuantity:     ticker.TotalBuy,
+		SellQuantity:    ticker.TotalSell,
+		Volume:          ticker.VolumeTraded,
+		AveragePrice:    ticker.AverageTradedPrice,
+		OI:              ticker.OI,
+		OIDayHigh:       ticker.OIHigh,
+		OIDayLow:        ticker.OILow,
+		NetChange:       ticker.PriceChange,
+		OHLC: OHLC{
+			Open:  ticker.Open,
+			High:  ticker.High,
+			Low:   ticker.Low,
+			Close: ticker.Close,
+		},
+		Depth: Depth{
+			Buy:  append([]LimitOrder{}, ticker.Depth.Buy...),
+			Sell: append([]LimitOrder{}, ticker.Depth.Sell...),
+		},
+		Source: QuoteSourceWebsocket,
+		Age:    tickAge(ticker),
+	}
+	if quote.NetChange == 0 && ticker.Close > 0 {
+		quote.NetChange = ticker.LastPrice - ticker.Close
+	}
+	if !ticker.ExchangeTimestamp.IsZero() && ticker.ExchangeTimestamp.Unix() > 0 {
+		quote.Timestamp = ticker.ExchangeTimestamp.In(IST).Format(QuoteTimeFormat)
+	}
+	if !ticker.LastTradedTimestamp.IsZero() && ticker.LastTradedTimestamp.Unix() > 0 {
+		quote.LastTradeTime = ticker.LastTradedTimestamp.In(IST).Format(QuoteTimeFormat)
+	}
+	return quote
+}
+
+// GetQuote follows the client's QuotePolicy. WEB logins read the websocket
+// pipeline and report a stale tick only when no newer one arrives. API logins
+// try the preferred source first and fall back to the other, going straight
+// to REST when a fresh quote is forced.
 func (kite *Kite) GetQuote(ctx *context.Context, exchange string, tradingSymbol string) (*Quote, error) {
 
 	k := *(*kite).Creds
+	policy := kite.GetQuotePolicy()
 
 	// For WEB login type, use WebSocket pipeline data with prioritization
 	if k["LoginType"] == "WEB" {
 		return kite.getQuoteFromWebSocketWithPriority(ctx, exchange, tradingSymbol)
 	}
 
+	if policy.ForceFresh || policy.Prefer == QuoteSourceREST {
+		quote, err := kite.getQuoteFromAPI(ctx, exchange, tradingSymbol)
+		if err == nil || policy.ForceFresh {
+			return quote, err
+		}
+		return kite.getQuoteFromWebSocketWithPriority(ctx, exchange, tradingSymbol)
+	}
+
 	// For API login type, try WebSocket first, fallback to API
 	quote, err := kite.getQuoteFromWebSocketWithPriority(ctx, exchange, tradingSymbol)
-	if err == nil {
+	if err == nil && !quote.Stale {
 		return quote, nil
 	}
-
-	// Fallback to API call for non-WEB login types
-	url := k["Url"] + "/quote?i=" + exchange + ":" + url.QueryEscape(tradingSymbol)
-	headers := make(map[string]string)
-	headers["authorization"] = k["Token"]
-	headers["content-type"] = "application/x-www-form-urlencoded"
-
-	response, _, err := requests.Get(ctx, url, headers)
-
-	if err != nil {
-		return nil, err
+	apiQuote, apiErr := kite.getQuoteFromAPI(ctx, exchange, tradingSymbol)
+	if apiErr != nil && err == nil {
+		return quote, nil
 	}
-	var respData *QuoteResponsePayload
-	err = json.Unmarshal(response, &respData)
+	return apiQuote, apiErr
+}
+
+// GetLastPrice returns the last price of the quote GetQuote selects and logs
+// a warning when that price comes from a stale tick
+func (kite *Kite) GetLastPrice(ctx *context.Context, exchange string, tradingSymbol string) (float64, error) {
+	quote, err := kite.GetQuote(ctx, exchange, tradingSymbol)
 	if err != nil {
-		return nil, err
+		return 0.0, err
 	}
-
-	if respData.Data == nil {
-		return nil, errors.New(respData.Message)
-
+	if quote.Stale {
+		log.Warnf("last price of %s:%s is %.0fs old", exchange, tradingSymbol, quote.Age)
 	}
-
-	return (respData.Data)[exchange+":"+tradingSymbol], nil
+	return quote.LastPrice, nil
 }
 
-func (kite *Kite) GetLastPrice(ctx *context.Context, exchange string, tradingSymbol string) (float64, error) {
+// getQuoteFromAPI fetches the quote from the REST API
+func (kite *Kite) getQuoteFromAPI(ctx *context.Context, exchange string, tradingSymbol string) (*Quote, error) {
 
 	k := *(*kite).Creds
-
-	// For WEB login type, use WebSocket pipeline data with prioritization
-	if k["LoginType"] == "WEB" {
-		return kite.getLastPriceFromWebSocketWithPriority(ctx, exchange, tradingSymbol)
-	}
-
-	// For API login type, try WebSocket first, fallback to API
-	price, err := kite.getLastPriceFromWebSocketWithPriority(ctx, exchange, tradingSymbol)
-	if err == nil {
-		return price, nil
-	}
-
-	// Fallback to API call for non-WEB login types
 	url := k["Url"] + "/quote?i=" + exchange + ":" + url.QueryEscape(tradingSymbol)
 	headers := make(map[string]string)
 	headers["authorization"] = k["Token"]
@@ -75,21 +118,25 @@ func (kite *Kite) GetLastPrice(ctx *context.Context, exchange string, tradingSym
 	response, _, err := requests.Get(ctx, url, headers)
 
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
 	var respData *QuoteResponsePayload
 	err = json.Unmarshal(response, &respData)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if respData.Data == nil {
-		return 0, errors.New(respData.Message)
+		return nil, errors.New(respData.Message)
 
 	}
-	price = (respData.Data)[exchange+":"+tradingSymbol].LastPrice
 
-	return price, nil
+	quote, ok := (respData.Data)[exchange+":"+tradingSymbol]
+	if !ok || quote == nil {
+		return nil, errors.New("quote_not_found")
+	}
+	quote.Source = QuoteSourceREST
+	return quote, nil
 }
 
 // getQuoteFromWebSocket retrieves quote data from the WebSocket pipeline
@@ -113,32 +160,8 @@ func (kite *Kite) getQuoteFromWebSocket(exchange string, tradingSymbol string) (
 		}
 	}
 
-	// Convert KiteTicker to Quote format
-	quote := &Quote{
-		LastPrice: ticker.LastPrice,
-		Depth: struct {
-			Buy []struct {
-				Price float64 `json:"price"`
-			} `json:"buy"`
-			Sell []struct {
-				Price float64 `json:"price"`
-			} `json:"sell"`
-		}{},
-	}
-
-	// Convert depth data
-	for _, buyOrder := range ticker.Depth.Buy {
-		quote.Depth.Buy = append(quote.Depth.Buy, struct {
-			Price float64 `json:"price"`
-		}{Price: buyOrder.Price})
-	}
-
-	for _, sellOrder := range ticker.Depth.Sell {
-		quote.Depth.Sell = append(quote.Depth.Sell, struct {
-			Price float64 `json:"price"`
-		}{Price: sellOrder.Price})
-	}
-
+	quote := QuoteFromTicker(ticker)
+	quote.Stale = !kite.GetQuotePolicy().Fresh(ticker)
 	return quote, nil
 }
 
@@ -182,7 +205,8 @@ func (kite *Kite) RequestQuoteFromWebSocket(ctx *context.Context, exchange strin
 	// If we have active ticker clients, subscribe to this token
 	if len(kite.TickerClients) > 0 {
 		for _, client := range kite.TickerClients {
-			if client != nil {
+			// Tokens streamed in full or 20 depth already carry the quote
+			if client != nil && client.mode(instrument.Token) < modeFull {
 				// Subscribe to quote data for this token
 				err := client.SubscribeQuote(ctx, []uint32{instrument.Token})
 				if err != nil {
@@ -248,64 +272,41 @@ func (kite *Kite) GetLastPriceWithSubscription(ctx *context.Context, exchange st
 	return price, nil
 }
 
-// getQuoteFromWebSocketWithPriority retrieves quote data by adding to batch and waiting
+// getQuoteFromWebSocketWithPriority retrieves quote data by adding to batch and waiting.
+// Stale ticks, and any cached tick when a fresh quote is forced, wait for a
+// newer tick; a stale tick is returned flagged when none arrives.
 func (kite *Kite) getQuoteFromWebSocketWithPriority(ctx *context.Context, exchange string, tradingSymbol string) (*Quote, error) {
 	// Initialize WebSocket if not available
 	if kite.TickSymbolMap == nil {
 		return nil, fmt.Errorf("websocket not initialized yet")
 	}
 
+	policy := kite.GetQuotePolicy()
+	notBefore := policy.notBefore(clock.Now())
+
 	// Try immediate lookup first
 	quote, err := kite.getQuoteFromWebSocket(exchange, tradingSymbol)
-	if err == nil {
+	if err == nil && !quote.Stale && !policy.ForceFresh {
 		return quote, nil
 	}
 
-	// If not found, add to current batch and wait for data
-	err = kite.addToBatchAndWait(ctx, exchange, tradingSymbol)
-	if err != nil {
-		return nil, fmt.Errorf("failed to add symbol to batch: %v", err)
-	}
-
-	// Try again after adding to batch
-	quote, err = kite.getQuoteFromWebSocket(exchange, tradingSymbol)
-	if err != nil {
-		return nil, fmt.Errorf("symbol %s still not available after adding to batch", exchange+":"+tradingSymbol)
+	// If not found or too old, add to current batch and wait for data
+	waitErr := kite.addToBatchAndWait(ctx, exchange, tradingSymbol, notBefore)
+	if waitErr == nil {
+		return kite.getQuoteFromWebSocket(exchange, tradingSymbol)
 	}
-
-	return quote, nil
-}
-
-// getLastPriceFromWebSocketWithPriority retrieves last price by adding to batch and waiting
-func (kite *Kite) getLastPriceFromWebSocketWithPriority(ctx *context.Context, exchange string, tradingSymbol string) (float64, error) {
-	// Initialize WebSocket if not available
-	if kite.TickSymbolMap == nil {
-		return 0.0, fmt.Errorf("websocket not initialized yet")
+	if err == nil && !policy.ForceFresh {
+		return quote, nil
 	}
-
-	// Try immediate lookup first
-	price, err := kite.getLastPriceFromWebSocket(exchange, tradingSymbol)
 	if err == nil {
-		return price, nil
-	}
-
-	// If not found, add to current batch and wait for data
-	err = kite.addToBatchAndWait(ctx, exchange, tradingSymbol)
-	if err != nil {
-		return 0.0, fmt.Errorf("failed to add symbol to batch: %v", err)
-	}
-
-	// Try again after adding to batch
-	price, err = kite.getLastPriceFromWebSocket(exchange, tradingSymbol)
-	if err != nil {
-		return 0.0, fmt.Errorf("symbol %s still not available after adding to batch", exchange+":"+tradingSymbol)
+		return nil, fmt.Errorf("no tick for %s received since the quote was requested", exchange+":"+tradingSymbol)
 	}
-
-	return price, nil
+	return nil, fmt.Errorf("failed to add symbol to batch: %v", waitErr)
 }
 
 // addToBatchAndWait adds the symbol to the current WebSocket batch and waits for data
-func (kite *Kite) addToBatchAndWait(ctx *context.Context, exchange string, tradingSymbol string) error {
+// received no earlier than notBefore
+func (kite *Kite) addToBatchAndWait(ctx *context.Context, exchange string, tradingSymbol string, notBefore time.Time) error {
 	// Find the instrument token for the given symbol
 	if BrokerInstrumentTokens == nil {
 		return fmt.Errorf("instrument tokens not loaded")
@@ -324,7 +325,8 @@ func (kite *Kite) addToBatchAndWait(ctx *context.Context, exchange string, tradi
 
 	// Add this token to the current batch by subscribing to it
 	for _, client := range kite.TickerClients {
-		if client != nil {
+		// Resubscribing a 20 depth token in full would drop it to 5 levels
+		if client != nil && client.mode(instrument.Token) != modeDepth20 {
 			// Subscribe to full data for this token
 			err := client.SubscribeFull(ctx, []uint32{instrument.Token})
 			if err != nil {
@@ -342,13 +344,13 @@ func (kite *Kite) addToBatchAndWait(ctx *context.Context, exchange string, tradi
 		// Check if data is now available using mutex
 		kite.TickSymbolMapMutex.RLock()
 		ticker, exists := kite.TickSymbolMap[symbolKey]
-		if exists && ticker.LastPrice > 0 {
+		if exists && ticker.LastPrice > 0 && !ticker.ReceivedAt.Before(notBefore) {
 			kite.TickSymbolMapMutex.RUnlock()
 			return nil
 		}
 		// Also check with just trading symbol
 		ticker, exists = kite.TickSymbolMap[tradingSymbol]
-		if exists && ticker.LastPrice > 0 {
+		if exists && ticker.LastPrice > 0 && !ticker.ReceivedAt.Before(notBefore) {
 			kite.TickSymbolMapMutex.RUnlock()
 			return nil
 		}
@@ -0,0 +1,226 @@
+package kite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/souvik131/kite-go-library/requests"
+)
+
+// Maximum instruments Kite accepts in one call to each quote endpoint
+const (
+	MaxQuoteInstruments = 500
+	MaxOHLCInstruments  = 1000
+	MaxLTPInstruments   = 1000
+)
+
+type OHLCQuote struct {
+	InstrumentToken uint32  `json:"instrument_token"`
+	LastPrice       float64 `json:"last_price"`
+	OHLC            OHLC    `json:"ohlc"`
+	Source          string  `json:"source"`
+	Age             float64 `json:"age"`
+	Stale           bool    `json:"stale"`
+}
+
+type LTPQuote struct {
+	InstrumentToken uint32  `json:"instrument_token"`
+	LastPrice       float64 `json:"last_price"`
+	Source          string  `json:"source"`
+	Age             float64 `json:"age"`
+	Stale           bool    `json:"stale"`
+}
+
+type OHLCResponsePayload struct {
+	Status    string                `json:"error"`
+	Message   string                `json:"message"`
+	ErrorType string                `json:"error_type"`
+	Data      map[string]*OHLCQuote `json:"data"`
+}
+
+type LTPResponsePayload struct {
+	Status    string               `json:"error"`
+	Message   string               `json:"message"`
+	ErrorType string               `json:"error_type"`
+	Data      map[string]*LTPQuote `json:"data"`
+}
+
+// GetQuotes returns full quotes keyed by "EXCHANGE:SYMBOL". Lists longer than
+// MaxQuoteInstruments are fetched in chunks. WEB logins are answered from the
+// websocket feed, with ticks older than the QuotePolicy flagged stale and
+// symbols not in the feed left out.
+func (kite *Kite) GetQuotes(ctx *context.Context, keys []string) (map[string]*Quote, error) {
+	quotes := map[string]*Quote{}
+	if (*kite.Creds)["LoginType"] == "WEB" {
+		policy := kite.GetQuotePolicy()
+		for key, ticker := range kite.tickersFor(keys) {
+			quotes[key] = QuoteFromTicker(ticker)
+			quotes[key].Stale = !policy.Fresh(ticker)
+		}
+		return quotes, nil
+	}
+	err := kite.getQuoteChunks(ctx, "/quote", keys, MaxQuoteInstruments, func(response []byte) error {
+		var respData *QuoteResponsePayload
+		err := json.Unmarshal(response, &respData)
+		if err != nil {
+			return err
+		}
+		if respData == nil || respData.Data == nil {
+			if respData == nil {
+				return errors.New("kite_broker_api_issue")
+			}
+			return errors.New(respData.Status + ":" + respData.Message)
+		}
+		for key, quote := range respData.Data {
+			if quote == nil {
+				continue
+			}
+			quote.Source = QuoteSourceREST
+			quotes[key] = quote
+		}
+		return nil
+	})
+	return quotes, err
+}
+
+// GetOHLC returns the last price and day OHLC keyed by "EXCHANGE:SYMBOL",
+// chunked by MaxOHLCInstruments
+func (kite *Kite) GetOHLC(ctx *context.Context, keys []string) (map[string]*OHLCQuote, error) {
+	quotes := map[string]*OHLCQuote{}
+	if (*kite.Creds)["LoginType"] == "WEB" {
+		policy := kite.GetQuotePolicy()
+		for key, ticker := range kite.tickersFor(keys) {
+			quotes[key] = &OHLCQuote{
+				InstrumentToken: ticker.Token,
+				LastPrice:       ticker.LastPrice,
+				OHLC:            OHLC{Open: ticker.Open, High: ticker.High, Low: ticker.Low, Close: ticker.Close},
+				Source:          QuoteSourceWebsocket,
+				Age:             tickAge(ticker),
+				Stale:           !policy.Fresh(ticker),
+			}
+		}
+		return quotes, nil
+	}
+	err := kite.getQuoteChunks(ctx, "/quote/ohlc", keys, MaxOHLCInstruments, func(response []byte) error {
+		var respData *OHLCResponsePayload
+		err := json.Unmarshal(response, &respData)
+		if err != nil {
+			return err
+		}
+		if respData == nil || respData.Data == nil {
+			if respData == nil {
+				return errors.New("kite_broker_api_issue")
+			}
+			return errors.New(respData.Status + ":" + respData.Message)
+		}
+		for key, quote := range respData.Data {
+			if quote == nil {
+				continue
+			}
+			quote.Source = QuoteSourceREST
+			quotes[key] = quote
+		}
+		return nil
+	})
+	return quotes, err
+}
+
+// GetLTP returns the last traded price keyed by "EXCHANGE:SYMBOL", chunked by
+// MaxLTPInstruments
+func (kite *Kite) GetLTP(ctx *context.Context, keys []string) (map[string]*LTPQuote, error) {
+	quotes := map[string]*LTPQuote{}
+	if (*kite.Creds)["LoginType"] == "WEB" {
+		policy := kite.GetQuotePolicy()
+		for key, ticker := range kite.tickersFor(keys) {
+			quotes[key] = &LTPQuote{
+				InstrumentToken: ticker.Token,
+				LastPrice:       ticker.LastPrice,
+				Source:          QuoteSourceWebsocket,
+				Age:             tickAge(ticker),
+				Stale:           !policy.Fresh(ticker),
+			}
+		}
+		return quotes, nil
+	}
+	err := kite.getQuoteChunks(ctx, "/quote/ltp", keys, MaxLTPInstruments, func(response []byte) error {
+		var respData *LTPResponsePayload
+		err := json.Unmarshal(response, &respData)
+		if err != nil {
+			return err
+		}
+		if respData == nil || respData.Data == nil {
+			if respData == nil {
+				return errors.New("kite_broker_api_issue")
+			}
+			return errors.New(respData.Status + ":" + respData.Message)
+		}
+		for key, quote := range respData.Data {
+			if quote == nil {
+				continue
+			}
+			quote.Source = QuoteSourceREST
+			quotes[key] = quote
+		}
+		return nil
+	})
+	return quotes, err
+}
+
+// getQuoteChunks calls path once per chunk of at most size distinct keys and
+// hands each response to merge
+func (kite *Kite) getQuoteChunks(ctx *context.Context, path string, keys []string, size int, merge func([]byte) error) error {
+	k := *(*kite).Creds
+	headers := make(map[string]string)
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
+	unique := dedupeKeys(keys)
+	for start := 0; start < len(unique); start += size {
+		end := start + size
+		if end > len(unique) {
+			end = len(unique)
+		}
+		query := []string{}
+		for _, key := range unique[start:end] {
+			query = append(query, "i="+url.QueryEscape(key))
+		}
+		response, _, err := requests.Get(ctx, k["Url"]+path+"?"+strings.Join(query, "&"), headers)
+		if err != nil {
+			return err
+		}
+		err = merge(response)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tickersFor returns the cached ticks of the keys that are in the feed
+func (kite *Kite) tickersFor(keys []string) map[string]KiteTicker {
+	tickers := map[string]KiteTicker{}
+	kite.TickSymbolMapMutex.RLock()
+	defer kite.TickSymbolMapMutex.RUnlock()
+	for _, key := range keys {
+		if ticker, ok := kite.TickSymbolMap[key]; ok {
+			tickers[key] = ticker
+		}
+	}
+	return tickers
+}
+
+func dedupeKeys(keys []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, key)
+	}
+	return unique
+}
@@ -0,0 +1,74 @@
+package kite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/souvik131/kite-go-library/requests"
+)
+
+type Trade struct {
+	TradeId           string  `json:"trade_id"`
+	OrderId           string  `json:"order_id"`
+	ExchangeOrderId   string  `json:"exchange_order_id"`
+	TradingSymbol     string  `json:"tradingsymbol"`
+	Exchange          string  `json:"exchange"`
+	InstrumentToken   uint32  `json:"instrument_token"`
+	TransactionType   string  `json:"transaction_type"`
+	Product           string  `json:"product"`
+	AveragePrice      float64 `json:"average_price"`
+	Quantity          uint32  `json:"quantity"`
+	FillTimestamp     string  `json:"fill_timestamp"`
+	OrderTimestamp    string  `json:"order_timestamp"`
+	ExchangeTimestamp string  `json:"exchange_timestamp"`
+}
+
+type TradesResponsePayload struct {
+	Status    string   `json:"error"`
+	Message   string   `json:"message"`
+	ErrorType string   `json:"error_type"`
+	Data      []*Trade `json:"data"`
+}
+
+// GetTrades returns every fill executed during the day
+func (kite *Kite) GetTrades(ctx *context.Context) ([]*Trade, error) {
+	k := *(*kite).Creds
+	return kite.getTrades(ctx, k["Url"]+"/trades")
+}
+
+// GetOrderTrades returns the fills of a single order
+func (kite *Kite) GetOrderTrades(ctx *context.Context, orderId string) ([]*Trade, error) {
+	k := *(*kite).Creds
+	return kite.getTrades(ctx, k["Url"]+"/orders/"+orderId+"/trades")
+}
+
+func (kite *Kite) getTrades(ctx *context.Context, url string) ([]*Trade, error) {
+	k := *(*kite).Creds
+
+	headers := map[string]string{
+		"Connection":      "keep-alive",
+		"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		"Accept-Encoding": "gzip, deflate",
+		"Host":            "kite.zerodha.com",
+		"Accept":          "*/*",
+	}
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
+	res, code, cookie, err := requests.GetWithCookies(ctx, url, headers, k["Cookie"])
+	k["Cookie"] = cookie
+	if err != nil {
+		return nil, err
+	}
+
+	var respData *TradesResponsePayload
+	err = json.Unmarshal(res, &respData)
+	if err != nil {
+		return nil, err
+	}
+	if code == 200 && respData.Data != nil {
+		return respData.Data, nil
+	}
+	return nil, errors.New(respData.Status + ":" + respData.Message)
+}
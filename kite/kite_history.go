@@ -5,17 +5,160 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/souvik131/kite-go-library/requests"
 )
 
+type Interval string
+
+const (
+	IntervalMinute   Interval = "minute"
+	Interval3Minute  Interval = "3minute"
+	Interval5Minute  Interval = "5minute"
+	Interval10Minute Interval = "10minute"
+	Interval15Minute Interval = "15minute"
+	Interval30Minute Interval = "30minute"
+	Interval60Minute Interval = "60minute"
+	IntervalDay      Interval = "day"
+)
+
+// MaxHistoricalDays is the longest range Kite serves in one historical call
+// for each interval. Longer ranges are fetched in chunks of this many days.
+var MaxHistoricalDays = map[Interval]int{
+	IntervalMinute:   60,
+	Interval3Minute:  100,
+	Interval5Minute:  100,
+	Interval10Minute: 100,
+	Interval15Minute: 200,
+	Interval30Minute: 200,
+	Interval60Minute: 400,
+	IntervalDay:      2000,
+}
+
+// HistoricalTimeFormat is the from/to layout of historical requests, in IST
+const HistoricalTimeFormat = "2006-01-02 15:04:05"
+
+// ParseInterval validates a Kite candle interval
+func ParseInterval(interval string) (Interval, error) {
+	if _, ok := MaxHistoricalDays[Interval(interval)]; !ok {
+		return "", errors.New("interval_not_allowed")
+	}
+	return Interval(interval), nil
+}
+
+// Duration is the length of one candle of the interval
+func (interval Interval) Duration() time.Duration {
+	switch interval {
+	case IntervalDay:
+		return 24 * time.Hour
+	case IntervalMinute:
+		return time.Minute
+	}
+	minutes, err := strconv.Atoi(string(interval[:len(interval)-len("minute")]))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// ParseHistoricalRange parses from and to as YYYY-MM-DD or
+// YYYY-MM-DD HH:MM:SS in IST. A date-only to covers the whole day.
+func ParseHistoricalRange(from string, to string) (time.Time, time.Time, error) {
+	start, _, err := parseHistoricalTime(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, dateOnly, err := parseHistoricalTime(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if dateOnly {
+		end = end.Add(24*time.Hour - time.Second)
+	}
+	return start, end, nil
+}
+
+func parseHistoricalTime(value string) (time.Time, bool, error) {
+	t, err := time.ParseInLocation(HistoricalTimeFormat, value, IST)
+	if err == nil {
+		return t, false, nil
+	}
+	t, err = time.ParseInLocation(YYYYMMDD, value, IST)
+	if err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, errors.New("invalid_date")
+}
+
+// GetHistoricalCandles returns the candles of token between from and to.
+// Ranges longer than MaxHistoricalDays for the interval are split into
+// chunks and merged in time order without duplicates. Continuous stitches
+// expired futures contracts into one series.
+func (kite *Kite) GetHistoricalCandles(ctx *context.Context, token uint32, interval Interval, from time.Time, to time.Time, continuous bool) ([]*Candle, error) {
+	maxDays, ok := MaxHistoricalDays[interval]
+	if !ok {
+		return nil, errors.New("interval_not_allowed")
+	}
+	if to.Before(from) {
+		return nil, errors.New("invalid_date_range")
+	}
+
+	candles := []*Candle{}
+	seen := map[int64]bool{}
+	for start := from; !start.After(to); {
+		end := start.AddDate(0, 0, maxDays).Add(-time.Second)
+		if end.After(to) {
+			end = to
+		}
+		chunk, err := kite.getHistoricalChunk(ctx, token, interval, start, end, continuous)
+		if err != nil {
+			return nil, err
+		}
+		for _, candle := range chunk {
+			if seen[candle.Timestamp] {
+				continue
+			}
+			seen[candle.Timestamp] = true
+			candles = append(candles, candle)
+		}
+		start = end.Add(time.Second)
+	}
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Timestamp < candles[j].Timestamp
+	})
+	return candles, nil
+}
+
+// GetHistoricalMinutelyData takes the interval and the dates as strings, see
+// ParseInterval and ParseHistoricalRange
 func (kite *Kite) GetHistoricalMinutelyData(ctx *context.Context, token uint32, interval string, startDate string, endDate string) ([]*Candle, error) {
+	candleInterval, err := ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := ParseHistoricalRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return kite.GetHistoricalCandles(ctx, token, candleInterval, from, to, false)
+}
+
+func (kite *Kite) getHistoricalChunk(ctx *context.Context, token uint32, interval Interval, from time.Time, to time.Time, continuous bool) ([]*Candle, error) {
 
 	k := *(*kite).Creds
 
-	url := fmt.Sprintf("%v/instruments/historical/%v/minute?from=%v&to=%v&oi=1", k["Url"], token, startDate, endDate)
+	query := url.Values{}
+	query.Set("from", from.In(IST).Format(HistoricalTimeFormat))
+	query.Set("to", to.In(IST).Format(HistoricalTimeFormat))
+	query.Set("oi", "1")
+	if continuous {
+		query.Set("continuous", "1")
+	}
+	url := fmt.Sprintf("%v/instruments/historical/%v/%v?%v", k["Url"], token, interval, query.Encode())
 
 	headers := map[string]string{
 		"Connection":      "keep-alive",
@@ -38,6 +181,9 @@ func (kite *Kite) GetHistoricalMinutelyData(ctx *context.Context, token uint32,
 	if err != nil {
 		return nil, err
 	}
+	if respData == nil {
+		return nil, errors.New("kite_broker_api_issue")
+	}
 
 	if code == 200 && respData.Data != nil {
 		candles := []*Candle{}
@@ -88,9 +234,8 @@ func (kite *Kite) GetHistoricalMinutelyData(ctx *context.Context, token uint32,
 					}
 					c.OI = uint64(oiFloat)
 				}
-
-				candles = append(candles, c)
 			}
+			candles = append(candles, c)
 		}
 
 		return candles, nil
@@ -99,7 +244,7 @@ func (kite *Kite) GetHistoricalMinutelyData(ctx *context.Context, token uint32,
 }
 
 // GetHistoricalData - Enhanced function that accepts exchange and trading symbol
-func (kite *Kite) GetHistoricalData(ctx *context.Context, exchange string, tradingSymbol string, interval string, startDate string, endDate string) ([]*Candle, error) {
+func (kite *Kite) GetHistoricalData(ctx *context.Context, exchange string, tradingSymbol string, interval Interval, from time.Time, to time.Time, continuous bool) ([]*Candle, error) {
 	// Find the instrument token for the given symbol
 	if BrokerInstrumentTokens == nil {
 		return nil, fmt.Errorf("instrument tokens not loaded")
@@ -112,5 +257,5 @@ func (kite *Kite) GetHistoricalData(ctx *context.Context, exchange string, tradi
 	}
 
 	// Use the existing function with the found token
-	return kite.GetHistoricalMinutelyData(ctx, instrument.Token, interval, startDate, endDate)
+	return kite.GetHistoricalCandles(ctx, instrument.Token, interval, from, to, continuous)
 }
@@ -8,12 +8,12 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/hotp"
+	"github.com/souvik131/kite-go-library/clock"
 	"github.com/souvik131/kite-go-library/requests"
 )
 
@@ -85,8 +85,14 @@ func (kite *Kite) GetWebSocketClient(ctx *context.Context /*, receiveBinaryTicke
 	if loginType == "" {
 		log.Fatalln("Please ensure .env file has all the creds including TA_LOGINTYPE")
 	}
+	if loginType == "PAPER" {
+		loginType = "WEB"
+	}
 
 	k["LoginType"] = loginType
+	if kite.QuotePolicy == nil {
+		kite.QuotePolicy = QuotePolicyFromEnv()
+	}
 
 	if k["LoginType"] == "WEB" {
 		kws, err := GetWebsocketClientForWeb(ctx, k["Id"], k["Token"] /*, receiveBinaryTickers*/)
@@ -97,7 +103,7 @@ func (kite *Kite) GetWebSocketClient(ctx *context.Context /*, receiveBinaryTicke
 		go func() {
 
 			for err := range kws.ErrorChan {
-				log.Panicf("websocket client error : %v", err)
+				log.Errorf("websocket client error : %v", err)
 			}
 		}()
 		return kws, nil
@@ -126,11 +132,18 @@ func (kite *Kite) Login(ctx *context.Context) error {
 	if loginType == "" {
 		log.Fatalln("Please ensure .env  file has all the creds including TA_LOGINTYPE")
 	}
+	// Paper trading uses a web session for market data, orders are simulated
+	if loginType == "PAPER" {
+		loginType = "WEB"
+	}
 
 	k["LoginType"] = loginType
+	if kite.QuotePolicy == nil {
+		kite.QuotePolicy = QuotePolicyFromEnv()
+	}
 
 	if k["LoginType"] != "API" && k["LoginType"] != "WEB" {
-		return fmt.Errorf("LOGINTYPE not valid in .env . It should be WEB or API")
+		return fmt.Errorf("LOGINTYPE not valid in .env . It should be WEB, API or PAPER")
 	}
 
 	if k["LoginType"] == "WEB" {
@@ -213,7 +226,7 @@ func (kite *Kite) LoginWeb(ctx *context.Context) error {
 		return fmt.Errorf(string(body))
 	}
 
-	otp, err := hotp.GenerateCode(totp, uint64(time.Now().Unix()/30))
+	otp, err := hotp.GenerateCode(totp, uint64(clock.Now().Unix()/30))
 	if err != nil {
 
 		return err
@@ -366,7 +379,7 @@ func (kite *Kite) LoginApi(ctx *context.Context) error {
 	// log.Println("Stage 4: Hit Login API ")
 
 	//Hit TOTP API
-	otp, err := hotp.GenerateCode(k["Totp"], uint64(time.Now().Unix()/30))
+	otp, err := hotp.GenerateCode(k["Totp"], uint64(clock.Now().Unix()/30))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,360 @@
+package kite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/requests"
+)
+
+type MFInstrument struct {
+	TradingSymbol                   string  `csv:"tradingsymbol" json:"tradingsymbol"`
+	AMC                             string  `csv:"amc" json:"amc"`
+	Name                            string  `csv:"name" json:"name"`
+	PurchaseAllowed                 bool    `csv:"purchase_allowed" json:"purchase_allowed"`
+	RedemptionAllowed               bool    `csv:"redemption_allowed" json:"redemption_allowed"`
+	MinimumPurchaseAmount           float64 `csv:"minimum_purchase_amount" json:"minimum_purchase_amount"`
+	PurchaseAmountMultiplier        float64 `csv:"purchase_amount_multiplier" json:"purchase_amount_multiplier"`
+	MinimumAdditionalPurchaseAmount float64 `csv:"minimum_additional_purchase_amount" json:"minimum_additional_purchase_amount"`
+	MinimumRedemptionQuantity       float64 `csv:"minimum_redemption_quantity" json:"minimum_redemption_quantity"`
+	RedemptionQuantityMultiplier    float64 `csv:"redemption_quantity_multiplier" json:"redemption_quantity_multiplier"`
+	DividendType                    string  `csv:"dividend_type" json:"dividend_type"`
+	SchemeType                      string  `csv:"scheme_type" json:"scheme_type"`
+	Plan                            string  `csv:"plan" json:"plan"`
+	SettlementType                  string  `csv:"settlement_type" json:"settlement_type"`
+	LastPrice                       float64 `csv:"last_price" json:"last_price"`
+	LastPriceDate                   string  `csv:"last_price_date" json:"last_price_date"`
+}
+
+// MFOrder is a mutual fund purchase (by Amount) or redemption (by Quantity)
+type MFOrder struct {
+	TradingSymbol   string
+	TransactionType string
+	Amount          float64
+	Quantity        float64
+	Tag             string
+}
+
+type MFOrderPayload struct {
+	TradingSymbol   string `query:"tradingsymbol"`
+	TransactionType string `query:"transaction_type"`
+	Amount          string `query:"amount"`
+	Quantity        string `query:"quantity"`
+	Tag             string `query:"tag"`
+}
+
+type MFOrderStatus struct {
+	OrderId           string  `json:"order_id"`
+	ExchangeOrderId   string  `json:"exchange_order_id"`
+	TradingSymbol     string  `json:"tradingsymbol"`
+	OrderState        string  `json:"status"`
+	Remarks           string  `json:"status_message"`
+	Folio             string  `json:"folio"`
+	Fund              string  `json:"fund"`
+	OrderTimestamp    string  `json:"order_timestamp"`
+	ExchangeTimestamp string  `json:"exchange_timestamp"`
+	SettlementId      string  `json:"settlement_id"`
+	TransactionType   string  `json:"transaction_type"`
+	Variety           string  `json:"variety"`
+	PurchaseType      string  `json:"purchase_type"`
+	Quantity          float64 `json:"quantity"`
+	Amount            float64 `json:"amount"`
+	LastPrice         float64 `json:"last_price"`
+	AveragePrice      float64 `json:"average_price"`
+	PlacedBy          string  `json:"placed_by"`
+	Tag               string  `json:"tag"`
+}
+
+// MFSIP creates or modifies a systematic investment plan. Frequency is
+// weekly, monthly or quarterly; Status is active or paused and only used on
+// modification.
+type MFSIP struct {
+	TradingSymbol string
+	Amount        float64
+	Instalments   int
+	Frequency     string
+	InitialAmount float64
+	InstalmentDay int
+	Status        string
+	Tag           string
+}
+
+type MFSIPPayload struct {
+	TradingSymbol string `query:"tradingsymbol"`
+	Amount        string `query:"amount"`
+	Instalments   string `query:"instalments"`
+	Frequency     string `query:"frequency"`
+	InitialAmount string `query:"initial_amount"`
+	InstalmentDay string `query:"instalment_day"`
+	Status        string `query:"status"`
+	Tag           string `query:"tag"`
+}
+
+type MFSIPStatus struct {
+	SIPId                string  `json:"sip_id"`
+	TradingSymbol        string  `json:"tradingsymbol"`
+	Fund                 string  `json:"fund"`
+	DividendType         string  `json:"dividend_type"`
+	TransactionType      string  `json:"transaction_type"`
+	Status               string  `json:"status"`
+	SIPType              string  `json:"sip_type"`
+	Created              string  `json:"created"`
+	Frequency            string  `json:"frequency"`
+	InstalmentAmount     float64 `json:"instalment_amount"`
+	Instalments          int     `json:"instalments"`
+	LastInstalment       string  `json:"last_instalment"`
+	PendingInstalments   int     `json:"pending_instalments"`
+	InstalmentDay        int     `json:"instalment_day"`
+	CompletedInstalments int     `json:"completed_instalments"`
+	NextInstalment       string  `json:"next_instalment"`
+	TriggerPrice         float64 `json:"trigger_price"`
+	Tag                  string  `json:"tag"`
+}
+
+type MFHolding struct {
+	TradingSymbol string  `json:"tradingsymbol"`
+	Fund          string  `json:"fund"`
+	Folio         string  `json:"folio"`
+	Quantity      float64 `json:"quantity"`
+	AveragePrice  float64 `json:"average_price"`
+	LastPrice     float64 `json:"last_price"`
+	LastPriceDate string  `json:"last_price_date"`
+	PnL           float64 `json:"pnl"`
+	PledgedQty    float64 `json:"pledged_quantity"`
+}
+
+type MFResponsePayload struct {
+	Status    string          `json:"error"`
+	Message   string          `json:"message"`
+	ErrorType string          `json:"error_type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// FetchMFInstruments downloads the mutual fund instrument dump
+func (kite *Kite) FetchMFInstruments() ([]*MFInstrument, error) {
+	var insts []*MFInstrument
+
+	resp, err := http.Get("https://api.kite.trade/mf/instruments")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err = gocsv.Unmarshal(resp.Body, &insts); err != nil {
+		return nil, err
+	}
+	return insts, nil
+}
+
+// PlaceMFOrder places a purchase by amount or a redemption by quantity and
+// returns the order id
+func (kite *Kite) PlaceMFOrder(ctx *context.Context, order *MFOrder) (string, error) {
+	kPayload := &MFOrderPayload{
+		TradingSymbol:   order.TradingSymbol,
+		TransactionType: order.TransactionType,
+		Tag:             order.Tag,
+	}
+	switch order.TransactionType {
+	case "BUY":
+		if order.Amount <= 0 {
+			return "", errors.New("mf_amount_required")
+		}
+		kPayload.Amount = fmt.Sprintf("%v", order.Amount)
+	case "SELL":
+		if order.Quantity <= 0 && order.Amount <= 0 {
+			return "", errors.New("mf_quantity_or_amount_required")
+		}
+		if order.Quantity > 0 {
+			kPayload.Quantity = fmt.Sprintf("%v", order.Quantity)
+		} else {
+			kPayload.Amount = fmt.Sprintf("%v", order.Amount)
+		}
+	default:
+		return "", errors.New("transaction_type_not_allowed")
+	}
+
+	log.Infof("Placing the following mf order : %+v", kPayload)
+
+	k := *(*kite).Creds
+	var data struct {
+		OrderId string `json:"order_id"`
+	}
+	err := kite.mfRequest(ctx, "POST", k["Url"]+"/mf/orders", encodeQuery(kPayload), &data)
+	if err != nil {
+		return "", err
+	}
+	return data.OrderId, nil
+}
+
+func (kite *Kite) CancelMFOrder(ctx *context.Context, orderId string) error {
+	k := *(*kite).Creds
+	return kite.mfRequest(ctx, "DELETE", k["Url"]+"/mf/orders/"+orderId, "", nil)
+}
+
+// GetMFOrders returns the mutual fund orders of the last seven days
+func (kite *Kite) GetMFOrders(ctx *context.Context) ([]*MFOrderStatus, error) {
+	k := *(*kite).Creds
+	orders := []*MFOrderStatus{}
+	err := kite.mfRequest(ctx, "GET", k["Url"]+"/mf/orders", "", &orders)
+	return orders, err
+}
+
+func (kite *Kite) GetMFOrder(ctx *context.Context, orderId string) (*MFOrderStatus, error) {
+	k := *(*kite).Creds
+	var order *MFOrderStatus
+	err := kite.mfRequest(ctx, "GET", k["Url"]+"/mf/orders/"+orderId, "", &order)
+	return order, err
+}
+
+// PlaceMFSIP creates a SIP and returns its id
+func (kite *Kite) PlaceMFSIP(ctx *context.Context, sip *MFSIP) (string, error) {
+	if sip.Amount <= 0 || sip.Instalments == 0 || sip.Frequency == "" {
+		return "", errors.New("mf_sip_amount_instalments_frequency_required")
+	}
+	kPayload := sipPayload(sip)
+	kPayload.Status = ""
+
+	log.Infof("Placing the following sip : %+v", kPayload)
+
+	k := *(*kite).Creds
+	var data struct {
+		SIPId string `json:"sip_id"`
+	}
+	err := kite.mfRequest(ctx, "POST", k["Url"]+"/mf/sips", encodeQuery(kPayload), &data)
+	if err != nil {
+		return "", err
+	}
+	return data.SIPId, nil
+}
+
+// ModifyMFSIP changes the amount, instalments, frequency, day or status of a
+// SIP. Zero fields are left unchanged.
+func (kite *Kite) ModifyMFSIP(ctx *context.Context, sipId string, sip *MFSIP) error {
+	kPayload := sipPayload(sip)
+	kPayload.TradingSymbol = ""
+	kPayload.InitialAmount = ""
+
+	k := *(*kite).Creds
+	return kite.mfRequest(ctx, "PUT", k["Url"]+"/mf/sips/"+sipId, encodeQuery(kPayload), nil)
+}
+
+func (kite *Kite) CancelMFSIP(ctx *context.Context, sipId string) error {
+	k := *(*kite).Creds
+	return kite.mfRequest(ctx, "DELETE", k["Url"]+"/mf/sips/"+sipId, "", nil)
+}
+
+func (kite *Kite) GetMFSIPs(ctx *context.Context) ([]*MFSIPStatus, error) {
+	k := *(*kite).Creds
+	sips := []*MFSIPStatus{}
+	err := kite.mfRequest(ctx, "GET", k["Url"]+"/mf/sips", "", &sips)
+	return sips, err
+}
+
+func (kite *Kite) GetMFSIP(ctx *context.Context, sipId string) (*MFSIPStatus, error) {
+	k := *(*kite).Creds
+	var sip *MFSIPStatus
+	err := kite.mfRequest(ctx, "GET", k["Url"]+"/mf/sips/"+sipId, "", &sip)
+	return sip, err
+}
+
+func (kite *Kite) GetMFHoldings(ctx *context.Context) ([]*MFHolding, error) {
+	k := *(*kite).Creds
+	holdings := []*MFHolding{}
+	err := kite.mfRequest(ctx, "GET", k["Url"]+"/mf/holdings", "", &holdings)
+	return holdings, err
+}
+
+func sipPayload(sip *MFSIP) *MFSIPPayload {
+	kPayload := &MFSIPPayload{
+		TradingSymbol: sip.TradingSymbol,
+		Frequency:     sip.Frequency,
+		Status:        sip.Status,
+		Tag:           sip.Tag,
+	}
+	if sip.Amount > 0 {
+		kPayload.Amount = fmt.Sprintf("%v", sip.Amount)
+	}
+	if sip.Instalments != 0 {
+		kPayload.Instalments = fmt.Sprintf("%v", sip.Instalments)
+	}
+	if sip.InitialAmount > 0 {
+		kPayload.InitialAmount = fmt.Sprintf("%v", sip.InitialAmount)
+	}
+	if sip.InstalmentDay > 0 {
+		kPayload.InstalmentDay = fmt.Sprintf("%v", sip.InstalmentDay)
+	}
+	return kPayload
+}
+
+// encodeQuery form-encodes the query tagged fields of payload, skipping
+// empty values
+func encodeQuery(payload interface{}) string {
+	queries := make([]string, 0)
+	val := reflect.ValueOf(payload).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		fv := fmt.Sprintf("%v", val.Field(i))
+		if fv == "" {
+			continue
+		}
+		queries = append(queries, fmt.Sprintf("%v=%v", val.Type().Field(i).Tag.Get("query"), fv))
+	}
+	return strings.Join(queries, "&")
+}
+
+// mfRequest calls a mutual fund endpoint and decodes its data into out
+func (kite *Kite) mfRequest(ctx *context.Context, method string, url string, payload string, out interface{}) error {
+	k := *(*kite).Creds
+
+	headers := map[string]string{
+		"Connection":      "keep-alive",
+		"User-Agent":      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+		"Accept-Encoding": "gzip, deflate",
+		"Host":            "kite.zerodha.com",
+		"Accept":          "*/*",
+	}
+	headers["authorization"] = k["Token"]
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
+	var res []byte
+	var code int
+	var cookie string
+	var err error
+	switch method {
+	case "GET":
+		res, code, cookie, err = requests.GetWithCookies(ctx, url, headers, k["Cookie"])
+	case "POST":
+		res, code, cookie, err = requests.PostWithCookies(ctx, url, payload, headers, k["Cookie"])
+	case "DELETE":
+		res, code, cookie, err = requests.DeleteWithCookies(ctx, url, headers, k["Cookie"])
+	case "PUT":
+		cookie = k["Cookie"]
+		headers["Cookie"] = cookie
+		res, code, err = requests.Put(ctx, url, payload, headers)
+	default:
+		return errors.New("method_not_allowed")
+	}
+	k["Cookie"] = cookie
+	if err != nil {
+		return err
+	}
+
+	var respData *MFResponsePayload
+	err = json.Unmarshal(res, &respData)
+	if err != nil {
+		return err
+	}
+	if code != 200 || respData.Status == "error" {
+		return errors.New(respData.Status + ":" + respData.Message)
+	}
+	if out == nil || len(respData.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respData.Data, out)
+}
@@ -16,6 +16,12 @@ import (
 func (kite *Kite) PlaceOrder(ctx *context.Context, order *Order) (string, error) {
 	k := *(*kite).Creds
 
+	if kite.PreTradeCheck != nil {
+		if err := kite.PreTradeCheck(order); err != nil {
+			return "", err
+		}
+	}
+
 	kOrder := &OrderPayload{
 		Exchange:          order.Exchange,
 		TradingSymbol:     order.TradingSymbol,
@@ -40,13 +46,17 @@ func (kite *Kite) PlaceOrder(ctx *context.Context, order *Order) (string, error)
 		kOrder.Price = fmt.Sprintf("%v", order.Price)
 	case "MARKET":
 		i, err := (*kite).GetQuote(ctx, kOrder.Exchange, kOrder.TradingSymbol)
+		if err != nil {
+			return "", err
+		}
+		// Never price a market order off a stale tick unless asked to
+		if i.Stale && !order.AllowStaleQuote {
+			return "", errors.New("stale_quote")
+		}
 		lastPrice := 0.0
 		if len(i.Depth.Buy) > 0 && len(i.Depth.Sell) > 0 {
 			lastPrice = (i.Depth.Buy[0].Price + i.Depth.Sell[0].Price) / 2
 		}
-		if err != nil {
-			return "", err
-		}
 		if kOrder.TransactionType == "BUY" {
 			kOrder.Price = fmt.Sprintf("%v", math.Floor((lastPrice*(1+mpp/100))/tickSize)*tickSize)
 		}
@@ -105,6 +115,12 @@ func (kite *Kite) PlaceOrder(ctx *context.Context, order *Order) (string, error)
 func (kite *Kite) ModifyOrder(ctx *context.Context, orderId string, order *Order) error {
 	k := *(*kite).Creds
 
+	if kite.PreTradeCheck != nil {
+		if err := kite.PreTradeCheck(order); err != nil {
+			return err
+		}
+	}
+
 	kOrder := &OrderPayload{
 		Exchange:          order.Exchange,
 		TradingSymbol:     order.TradingSymbol,
@@ -129,13 +145,17 @@ func (kite *Kite) ModifyOrder(ctx *context.Context, orderId string, order *Order
 		kOrder.Price = fmt.Sprintf("%v", order.Price)
 	case "MARKET":
 		i, err := (*kite).GetQuote(ctx, kOrder.Exchange, kOrder.TradingSymbol)
+		if err != nil {
+			return err
+		}
+		// Never price a market order off a stale tick unless asked to
+		if i.Stale && !order.AllowStaleQuote {
+			return errors.New("stale_quote")
+		}
 		lastPrice := 0.0
 		if len(i.Depth.Buy) > 0 && len(i.Depth.Sell) > 0 {
 			lastPrice = (i.Depth.Buy[0].Price + i.Depth.Sell[0].Price) / 2
 		}
-		if err != nil {
-			return err
-		}
 		if kOrder.TransactionType == "BUY" {
 			kOrder.Price = fmt.Sprintf("%v", math.Floor((lastPrice*(1+mpp/100))/tickSize)*tickSize)
 		}
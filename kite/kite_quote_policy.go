@@ -0,0 +1,87 @@
+package kite
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/souvik131/kite-go-library/clock"
+)
+
+// Quote sources
+const (
+	QuoteSourceWebsocket = "websocket"
+	QuoteSourceREST      = "rest"
+)
+
+// DefaultQuoteMaxAge is how long a cached tick is trusted when
+// TA_QUOTE_MAX_AGE is not set
+const DefaultQuoteMaxAge = 60 * time.Second
+
+// QuotePolicy decides where quotes come from. Cached websocket ticks received
+// more than MaxAge ago are stale; a zero MaxAge trusts ticks of any age.
+// Prefer picks the first source tried by API logins and ForceFresh skips the
+// cache altogether. WEB logins have no REST quotes, so for them ForceFresh
+// waits for a tick received after the call.
+type QuotePolicy struct {
+	MaxAge     time.Duration
+	Prefer     string
+	ForceFresh bool
+}
+
+// QuotePolicyFromEnv reads TA_QUOTE_MAX_AGE (seconds), TA_QUOTE_PREFER
+// (websocket or rest) and TA_QUOTE_FORCE_FRESH (true or false)
+func QuotePolicyFromEnv() *QuotePolicy {
+	policy := &QuotePolicy{
+		MaxAge: DefaultQuoteMaxAge,
+		Prefer: QuoteSourceWebsocket,
+	}
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("TA_QUOTE_MAX_AGE")), 64); err == nil && seconds >= 0 {
+		policy.MaxAge = time.Duration(seconds * float64(time.Second))
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("TA_QUOTE_PREFER"))) == QuoteSourceREST {
+		policy.Prefer = QuoteSourceREST
+	}
+	if forceFresh, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("TA_QUOTE_FORCE_FRESH"))); err == nil {
+		policy.ForceFresh = forceFresh
+	}
+	return policy
+}
+
+// GetQuotePolicy returns the client's policy. Login sets it from the
+// environment when it has not been set already.
+func (kite *Kite) GetQuotePolicy() *QuotePolicy {
+	if kite.QuotePolicy == nil {
+		return QuotePolicyFromEnv()
+	}
+	return kite.QuotePolicy
+}
+
+// Fresh reports whether the cached tick is within MaxAge. ForceFresh is not
+// applied here, it only changes how quotes are looked up.
+func (policy *QuotePolicy) Fresh(ticker KiteTicker) bool {
+	if policy.MaxAge <= 0 {
+		return true
+	}
+	return !ticker.ReceivedAt.Before(clock.Now().Add(-policy.MaxAge))
+}
+
+// notBefore is the oldest receive time a lookup starting at now accepts
+func (policy *QuotePolicy) notBefore(now time.Time) time.Time {
+	if policy.ForceFresh {
+		return now
+	}
+	if policy.MaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-policy.MaxAge)
+}
+
+// tickAge is the time in seconds since the tick was received
+func tickAge(ticker KiteTicker) float64 {
+	if ticker.ReceivedAt.IsZero() {
+		return 0
+	}
+	return clock.Since(ticker.ReceivedAt).Seconds()
+}
@@ -0,0 +1,59 @@
+package kite
+
+// Segment is the exchange segment of an instrument, carried in the low byte
+// of its instrument token
+type Segment uint8
+
+const (
+	SegmentNSECM   Segment = 1
+	SegmentNSEFO   Segment = 2
+	SegmentNSECD   Segment = 3
+	SegmentBSECM   Segment = 4
+	SegmentBSEFO   Segment = 5
+	SegmentBSECD   Segment = 6
+	SegmentMCXFO   Segment = 7
+	SegmentMCXSX   Segment = 8
+	SegmentIndices Segment = 9
+)
+
+var segmentNames = map[Segment]string{
+	SegmentNSECM:   "NSE",
+	SegmentNSEFO:   "NFO",
+	SegmentNSECD:   "CDS",
+	SegmentBSECM:   "BSE",
+	SegmentBSEFO:   "BFO",
+	SegmentBSECD:   "BCD",
+	SegmentMCXFO:   "MCX",
+	SegmentMCXSX:   "MCXSX",
+	SegmentIndices: "INDICES",
+}
+
+// SegmentOf returns the segment of the instrument token
+func SegmentOf(token uint32) Segment {
+	return Segment(token & 0xff)
+}
+
+// String is the exchange name of the segment as used in instrument keys
+func (segment Segment) String() string {
+	if name, ok := segmentNames[segment]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// Divisor converts the segment's binary prices, in paise for most segments,
+// to rupees. NSE currency prices carry seven decimals and BSE currency four.
+func (segment Segment) Divisor() float64 {
+	switch segment {
+	case SegmentNSECD:
+		return 10000000
+	case SegmentBSECD:
+		return 10000
+	}
+	return 100
+}
+
+// PriceDivisor is the divisor of the token's binary prices
+func PriceDivisor(token uint32) float64 {
+	return SegmentOf(token).Divisor()
+}
@@ -0,0 +1,73 @@
+package kite
+
+import (
+	"strings"
+)
+
+// IndexUnderlyings maps index derivative names to the index they are
+// settled against
+var IndexUnderlyings = map[string]string{
+	"NIFTY":      "NSE:NIFTY 50",
+	"BANKNIFTY":  "NSE:NIFTY BANK",
+	"FINNIFTY":   "NSE:NIFTY FIN SERVICE",
+	"MIDCPNIFTY": "NSE:NIFTY MID SELECT",
+	"NIFTYNXT50": "NSE:NIFTY NEXT 50",
+	"SENSEX":     "BSE:SENSEX",
+	"BANKEX":     "BSE:BANKEX",
+	"SENSEX50":   "BSE:SENSEX50",
+}
+
+// UnderlyingOf returns the "EXCHANGE:SYMBOL" key of the instrument a
+// derivative is priced off: the index for index derivatives, the cash stock
+// for stock derivatives and the nearest future for commodity and currency
+// options. Cash instruments are their own underlying.
+func UnderlyingOf(instrument *Instrument) string {
+	switch instrument.InstrumentType {
+	case "FUT", "CE", "PE":
+	default:
+		return instrument.Exchange + ":" + instrument.TradingSymbol
+	}
+
+	if underlying, ok := IndexUnderlyings[instrument.Name]; ok {
+		return underlying
+	}
+	switch instrument.Exchange {
+	case "NFO":
+		return "NSE:" + instrument.Name
+	case "BFO":
+		return "BSE:" + instrument.Name
+	}
+	if instrument.InstrumentType == "FUT" {
+		return instrument.Exchange + ":" + instrument.TradingSymbol
+	}
+	return nearestFuture(instrument)
+}
+
+// nearestFuture returns the first future of the same name expiring on or
+// after the option
+func nearestFuture(option *Instrument) string {
+	key := ""
+	expiry := ""
+	if BrokerInstrumentTokens == nil {
+		return key
+	}
+	for _, instrument := range *BrokerInstrumentTokens {
+		if instrument.InstrumentType != "FUT" || instrument.Exchange != option.Exchange || instrument.Name != option.Name {
+			continue
+		}
+		if instrument.Expiry < option.Expiry {
+			continue
+		}
+		if expiry == "" || instrument.Expiry < expiry {
+			expiry = instrument.Expiry
+			key = instrument.Exchange + ":" + instrument.TradingSymbol
+		}
+	}
+	return key
+}
+
+// SplitKey splits an "EXCHANGE:SYMBOL" key
+func SplitKey(key string) (string, string) {
+	exchange, tradingSymbol, _ := strings.Cut(key, ":")
+	return exchange, tradingSymbol
+}
@@ -13,6 +13,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/souvik131/kite-go-library/clock"
 	"github.com/souvik131/kite-go-library/ws"
 )
 
@@ -50,6 +51,7 @@ func getWebsocketClient(ctx *context.Context, rawQuery string /*, receiveBinaryT
 		FullTokens:                 map[uint32]bool{},
 		QuoteTokens:                map[uint32]bool{},
 		LtpTokens:                  map[uint32]bool{},
+		Depth20Tokens:              map[uint32]bool{},
 		HeartBeatIntervalInSeconds: HeartBeatIntervalInSeconds,
 		// ReceiveBinaryTickers:       receiveBinaryTickers,
 	}
@@ -105,6 +107,9 @@ func (k *TickerClient) Serve(ctx *context.Context) {
 			if reader.Error != nil {
 				log.Error("websocket reader error: ", reader.Error)
 				k.ErrorChan <- reader.Error
+				if (*ctx).Err() == nil {
+					go k.Reconnect(ctx)
+				}
 				return
 			}
 			switch reader.MessageType {
@@ -139,52 +144,60 @@ func (k *TickerClient) Reconnect(ctx *context.Context) error {
 }
 
 func (k *TickerClient) Resubscribe(ctx *context.Context) error {
-
-	keys := make([]uint32, 0, len(k.LtpTokens))
-	for k2 := range k.LtpTokens {
-		keys = append(keys, k2)
-	}
-	for len(keys) > 0 {
-		minLen := int(math.Min(float64(BufferSize), float64(len(keys))))
-		keys = keys[0:minLen]
-		err := k.SubscribeLTP(ctx, keys[minLen:])
-		if err != nil {
-			return err
+	k.TokensMutex.RLock()
+	ltp := tokensOf(k.LtpTokens)
+	quote := tokensOf(k.QuoteTokens)
+	full := tokensOf(k.FullTokens)
+	depth20 := tokensOf(k.Depth20Tokens)
+	k.TokensMutex.RUnlock()
+
+	for _, group := range []struct {
+		tokens    []uint32
+		subscribe func(ctx *context.Context, tokens []uint32) error
+	}{
+		{ltp, k.SubscribeLTP},
+		{quote, k.SubscribeQuote},
+		{full, k.SubscribeFull},
+		{depth20, k.SubscribeDepth20},
+	} {
+		keys := group.tokens
+		for len(keys) > 0 {
+			minLen := int(math.Min(float64(BufferSize), float64(len(keys))))
+			err := group.subscribe(ctx, keys[0:minLen])
+			if err != nil {
+				return err
+			}
+			keys = keys[minLen:]
 		}
 	}
 
-	keys = make([]uint32, 0, len(k.QuoteTokens))
-	for k2 := range k.QuoteTokens {
-		keys = append(keys, k2)
-	}
-
-	for len(keys) > 0 {
-		minLen := int(math.Min(float64(BufferSize), float64(len(keys))))
-		keys = keys[0:minLen]
-		err := k.SubscribeQuote(ctx, keys[minLen:])
+	k.tapsMutex.Lock()
+	taps := append([]*TickerClient{}, k.taps...)
+	k.tapsMutex.Unlock()
+	for _, tap := range taps {
+		err := tap.Resubscribe(ctx)
 		if err != nil {
 			return err
 		}
 	}
+	return nil
 
-	keys = make([]uint32, 0, len(k.FullTokens))
-	for k2 := range k.FullTokens {
-		keys = append(keys, k2)
-	}
+}
 
-	for len(keys) > 0 {
-		minLen := int(math.Min(float64(BufferSize), float64(len(keys))))
-		keys = keys[0:minLen]
-		err := k.SubscribeFull(ctx, keys[minLen:])
-		if err != nil {
-			return err
-		}
+func tokensOf(tokens map[uint32]bool) []uint32 {
+	keys := make([]uint32, 0, len(tokens))
+	for token := range tokens {
+		keys = append(keys, token)
 	}
-
-	return nil
-
+	return keys
 }
+
 func (k *TickerClient) SubscribeLTP(ctx *context.Context, tokens []uint32) error {
+	k.setMode(modeLTP, tokens)
+	if tokens = k.unshared(modeLTP, tokens); len(tokens) == 0 {
+		return nil
+	}
+
 	r := &Request{
 		Message: "mode",
 		Tokens: []interface{}{
@@ -197,17 +210,15 @@ func (k *TickerClient) SubscribeLTP(ctx *context.Context, tokens []uint32) error
 	}
 	r.Tokens = append(r.Tokens, iTokens)
 
-	k.TokensMutex.Lock()
-	for _, t := range tokens {
-		tInt := t
-		k.LtpTokens[tInt] = true
-	}
-	k.TokensMutex.Unlock()
-
 	return k.writeTextRequest(ctx, r)
 }
 
 func (k *TickerClient) SubscribeFull(ctx *context.Context, tokens []uint32) error {
+	k.setMode(modeFull, tokens)
+	if tokens = k.unshared(modeFull, tokens); len(tokens) == 0 {
+		return nil
+	}
+
 	r := &Request{
 		Message: "mode",
 		Tokens: []interface{}{
@@ -221,51 +232,231 @@ func (k *TickerClient) SubscribeFull(ctx *context.Context, tokens []uint32) erro
 	}
 	r.Tokens = append(r.Tokens, iTokens)
 
-	k.TokensMutex.Lock()
+	return k.writeTextRequest(ctx, r)
+}
+
+// Depth20Mode is the mode requested by SubscribeDepth20. Kite only enables
+// 20 depth for eligible accounts; others keep receiving 5 levels.
+var Depth20Mode = "20depth"
+
+// SubscribeDepth20 subscribes the tokens in full mode with 20 levels of
+// market depth a side
+func (k *TickerClient) SubscribeDepth20(ctx *context.Context, tokens []uint32) error {
+	k.setMode(modeDepth20, tokens)
+	if tokens = k.unshared(modeDepth20, tokens); len(tokens) == 0 {
+		return nil
+	}
+
+	r := &Request{
+		Message: "mode",
+		Tokens: []interface{}{
+			Depth20Mode,
+		},
+	}
+
+	iTokens := []uint32{}
 	for _, t := range tokens {
-		tInt := t
-		k.FullTokens[tInt] = true
+		iTokens = append(iTokens, t)
 	}
-	k.TokensMutex.Unlock()
+	r.Tokens = append(r.Tokens, iTokens)
 
 	return k.writeTextRequest(ctx, r)
 }
 
 func (k *TickerClient) SubscribeQuote(ctx *context.Context, tokens []uint32) error {
+	k.setMode(modeQuote, tokens)
+	if tokens = k.unshared(modeQuote, tokens); len(tokens) == 0 {
+		return nil
+	}
+
 	r := &Request{
 		Message: "subscribe",
 		Tokens:  []interface{}{},
 	}
-
-	k.TokensMutex.Lock()
 	for _, t := range tokens {
-		tInt := t
-		r.Tokens = append(r.Tokens, tInt)
-		k.QuoteTokens[tInt] = true
+		r.Tokens = append(r.Tokens, t)
 	}
-	k.TokensMutex.Unlock()
 
 	return k.writeTextRequest(ctx, r)
 }
 
-func (k *TickerClient) Unsubscribe(ctx *context.Context, tokens []uint32) error {
-	r := &Request{
-		Message: "unsubscribe",
-		Tokens:  []interface{}{},
+// Subscription modes in increasing order of what a tick carries
+const (
+	modeNone = iota
+	modeLTP
+	modeQuote
+	modeFull
+	modeDepth20
+)
+
+// setMode records the tokens under mode and takes them out of the other mode
+// sets, as the connection streams a token in one mode at a time
+func (k *TickerClient) setMode(mode int, tokens []uint32) {
+	k.TokensMutex.Lock()
+	defer k.TokensMutex.Unlock()
+
+	sets := map[int]map[uint32]bool{
+		modeLTP:     k.LtpTokens,
+		modeQuote:   k.QuoteTokens,
+		modeFull:    k.FullTokens,
+		modeDepth20: k.Depth20Tokens,
 	}
+	for _, t := range tokens {
+		for m, set := range sets {
+			if m == mode {
+				set[t] = true
+			} else {
+				delete(set, t)
+			}
+		}
+	}
+}
+
+// mode returns the mode the token is subscribed in, or modeNone
+func (k *TickerClient) mode(token uint32) int {
+	k.TokensMutex.RLock()
+	defer k.TokensMutex.RUnlock()
+
+	switch {
+	case k.Depth20Tokens[token]:
+		return modeDepth20
+	case k.FullTokens[token]:
+		return modeFull
+	case k.QuoteTokens[token]:
+		return modeQuote
+	case k.LtpTokens[token]:
+		return modeLTP
+	}
+	return modeNone
+}
 
+func (k *TickerClient) Unsubscribe(ctx *context.Context, tokens []uint32) error {
 	k.TokensMutex.Lock()
 	for _, t := range tokens {
-		r.Tokens = append(r.Tokens, t)
 		delete(k.QuoteTokens, t)
 		delete(k.LtpTokens, t)
 		delete(k.FullTokens, t)
+		delete(k.Depth20Tokens, t)
 	}
 	k.TokensMutex.Unlock()
+	if tokens = k.unshared(modeNone, tokens); len(tokens) == 0 {
+		return nil
+	}
+
+	r := &Request{
+		Message: "unsubscribe",
+		Tokens:  []interface{}{},
+	}
+	for _, t := range tokens {
+		r.Tokens = append(r.Tokens, t)
+	}
 
 	return k.writeTextRequest(ctx, r)
 }
 
+// Tap returns a ticker sharing the client's connection, so several consumers
+// can stream over one socket within Kite's limit of three per API key. Once
+// tapped the client hands each tick to the taps subscribed to its token, and
+// every connect signal to all taps, instead of its own channels. A tap taken
+// while connected gets a connect signal straight away.
+//
+// A tap's subscriptions go out on the shared connection but never downgrade
+// a token another tap streams in a richer mode, nor unsubscribe one another
+// tap still holds. The client serves, reconnects and resubscribes for all.
+func (k *TickerClient) Tap() *TickerClient {
+	tap := &TickerClient{
+		Client:                     k.Client,
+		TickerChan:                 make(chan KiteTicker, BufferSize),
+		BinaryTickerChan:           make(chan []byte, BufferSize),
+		ConnectChan:                make(chan struct{}, 10),
+		ErrorChan:                  k.ErrorChan,
+		FullTokens:                 map[uint32]bool{},
+		QuoteTokens:                map[uint32]bool{},
+		LtpTokens:                  map[uint32]bool{},
+		Depth20Tokens:              map[uint32]bool{},
+		HeartBeatIntervalInSeconds: HeartBeatIntervalInSeconds,
+		parent:                     k,
+	}
+
+	k.tapsMutex.Lock()
+	defer k.tapsMutex.Unlock()
+	k.taps = append(k.taps, tap)
+	if k.connected {
+		tap.ConnectChan <- struct{}{}
+	}
+	return tap
+}
+
+// unshared drops the tokens that the client's sibling taps stream in a mode
+// richer than mode, so they keep receiving it. It is a no-op for clients
+// that are not taps.
+func (k *TickerClient) unshared(mode int, tokens []uint32) []uint32 {
+	if k.parent == nil {
+		return tokens
+	}
+	k.parent.tapsMutex.Lock()
+	siblings := append([]*TickerClient{k.parent}, k.parent.taps...)
+	k.parent.tapsMutex.Unlock()
+
+	kept := []uint32{}
+	for _, t := range tokens {
+		shared := false
+		for _, sibling := range siblings {
+			if sibling != k && sibling.mode(t) > mode {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// publish hands the tick to TickerChan, or to the taps subscribed to its
+// token once the client is tapped. A tap whose buffer is full misses it.
+func (k *TickerClient) publish(ticker KiteTicker) {
+	k.tapsMutex.Lock()
+	taps := append([]*TickerClient{}, k.taps...)
+	k.tapsMutex.Unlock()
+
+	if len(taps) == 0 {
+		k.TickerChan <- ticker
+		return
+	}
+	for _, tap := range taps {
+		if tap.mode(ticker.Token) == modeNone {
+			continue
+		}
+		select {
+		case tap.TickerChan <- ticker:
+		default:
+			log.Warnf("websocket : dropped tick of %d, tap buffer full", ticker.Token)
+		}
+	}
+}
+
+// signalConnect signals ConnectChan, or every tap's once the client is
+// tapped. A tap with a signal already pending needs no other.
+func (k *TickerClient) signalConnect() {
+	k.tapsMutex.Lock()
+	k.connected = true
+	taps := append([]*TickerClient{}, k.taps...)
+	k.tapsMutex.Unlock()
+
+	if len(taps) == 0 {
+		k.ConnectChan <- struct{}{}
+		return
+	}
+	for _, tap := range taps {
+		select {
+		case tap.ConnectChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
 func (k *TickerClient) checkHeartBeat(ctx *context.Context) bool {
 	if time.Since(time.Unix(k.LastUpdatedTime.Load(), 0)).Seconds() > float64(k.HeartBeatIntervalInSeconds) {
 		k.HeartBeatIntervalInSeconds *= 2
@@ -299,11 +490,10 @@ func (k *TickerClient) onBinaryMessage(reader *ws.Reader) {
 	message := reader.Message
 	numOfPackets := binary.BigEndian.Uint16(message[0:2])
 	if numOfPackets > 0 {
-		// if k.ReceiveBinaryTickers {
-		k.BinaryTickerChan <- reader.Message
-		// } else {
+		if k.ReceiveBinaryTickers {
+			k.BinaryTickerChan <- reader.Message
+		}
 		k.ParseBinary(message)
-		// }
 	}
 
 }
@@ -321,46 +511,52 @@ func (k *TickerClient) ParseBinary(message []byte) {
 		packet := Packet(message[2 : packetSize+2])
 		values := packet.ParseBinary(int(math.Min(64, float64(len(packet)))))
 		ticker := KiteTicker{}
+		divisor := 100.0
 		if len(values) >= 2 {
 			ticker.Token = values[0]
 			ticker.TradingSymbol = TokenSymbolMap[ticker.Token]
-			ticker.LastPrice = float64(values[1]) / 100
+			ticker.Segment = SegmentOf(ticker.Token)
+			divisor = ticker.Segment.Divisor()
+			ticker.LastPrice = float64(values[1]) / divisor
 		}
 		switch len(values) {
 		case 2:
 		case 7:
-			ticker.High = float64(values[2]) / 100
-			ticker.Low = float64(values[3]) / 100
-			ticker.Open = float64(values[4]) / 100
-			ticker.Close = float64(values[5]) / 100
-			ticker.ExchangeTimestamp = time.Unix(int64(values[6]), 0)
+			// Index quote : high, low, open, close, change
+			ticker.High = float64(values[2]) / divisor
+			ticker.Low = float64(values[3]) / divisor
+			ticker.Open = float64(values[4]) / divisor
+			ticker.Close = float64(values[5]) / divisor
+			ticker.PriceChange = float64(int32(values[6])) / divisor
 		case 8:
-			ticker.High = float64(values[2]) / 100
-			ticker.Low = float64(values[3]) / 100
-			ticker.Open = float64(values[4]) / 100
-			ticker.Close = float64(values[5]) / 100
-			ticker.PriceChange = float64(values[6]) / 100
+			ticker.High = float64(values[2]) / divisor
+			ticker.Low = float64(values[3]) / divisor
+			ticker.Open = float64(values[4]) / divisor
+			ticker.Close = float64(values[5]) / divisor
+			ticker.PriceChange = float64(int32(values[6])) / divisor
 			ticker.ExchangeTimestamp = time.Unix(int64(values[7]), 0)
 		case 11:
 			ticker.LastTradedQuantity = values[2]
-			ticker.AverageTradedPrice = float64(values[3]) / 100
+			ticker.AverageTradedPrice = float64(values[3]) / divisor
 			ticker.VolumeTraded = values[4]
 			ticker.TotalBuy = values[5]
 			ticker.TotalSell = values[6]
-			ticker.High = float64(values[7]) / 100
-			ticker.Low = float64(values[8]) / 100
-			ticker.Open = float64(values[9]) / 100
-			ticker.Close = float64(values[10]) / 100
+			// Quote and full packets carry open, high, low, close in that order
+			ticker.Open = float64(values[7]) / divisor
+			ticker.High = float64(values[8]) / divisor
+			ticker.Low = float64(values[9]) / divisor
+			ticker.Close = float64(values[10]) / divisor
 		case 16:
 			ticker.LastTradedQuantity = values[2]
-			ticker.AverageTradedPrice = float64(values[3]) / 100
+			ticker.AverageTradedPrice = float64(values[3]) / divisor
 			ticker.VolumeTraded = values[4]
 			ticker.TotalBuy = values[5]
 			ticker.TotalSell = values[6]
-			ticker.High = float64(values[7]) / 100
-			ticker.Low = float64(values[8]) / 100
-			ticker.Open = float64(values[9]) / 100
-			ticker.Close = float64(values[10]) / 100
+			// Quote and full packets carry open, high, low, close in that order
+			ticker.Open = float64(values[7]) / divisor
+			ticker.High = float64(values[8]) / divisor
+			ticker.Low = float64(values[9]) / divisor
+			ticker.Close = float64(values[10]) / divisor
 			ticker.LastTradedTimestamp = time.Unix(int64(values[11]), 0)
 			ticker.OI = values[12]
 			ticker.OIHigh = values[13]
@@ -370,38 +566,36 @@ func (k *TickerClient) ParseBinary(message []byte) {
 			log.Warn("unknown length of packet: ", len(values), " values: ", values)
 		}
 
-		if len(packet) > 64 {
-
-			packet = packet[64:]
-
-			values := packet.ParseMarketDepth()
-			lobDepth := len(values) / 6
-
-			for {
-				if len(values) == 0 {
-
-					break
-				}
-				qty := values[0]
-				price := float64(values[1]) / 100
-				orders := values[2]
-				if len(ticker.Depth.Buy) < lobDepth {
-					ticker.Depth.Buy = append(ticker.Depth.Buy, LimitOrder{Price: price, Quantity: qty, Orders: orders})
-				} else {
-
-					ticker.Depth.Sell = append(ticker.Depth.Sell, LimitOrder{Price: price, Quantity: qty, Orders: orders})
-				}
-				values = values[3:]
-
-			}
+		if len(packet) > QuotePacketSize {
+			ticker.Depth = packet.ParseDepth(divisor)
 		}
-		k.TickerChan <- ticker
+		k.publish(ticker)
 		if len(message) > int(packetSize+2) {
 			message = message[packetSize+2:]
 		}
 	}
 }
 
+// StoreTick caches the ticker in TickSymbolMap under its trading symbol and
+// its exchange:symbol key so quotes can be served from the websocket feed
+func (kite *Kite) StoreTick(ticker KiteTicker) {
+	kite.TickSymbolMapMutex.Lock()
+	defer kite.TickSymbolMapMutex.Unlock()
+
+	if kite.TickSymbolMap == nil {
+		kite.TickSymbolMap = map[string]KiteTicker{}
+	}
+	if ticker.ReceivedAt.IsZero() {
+		ticker.ReceivedAt = clock.Now()
+	}
+	if ticker.TradingSymbol != "" {
+		kite.TickSymbolMap[ticker.TradingSymbol] = ticker
+	}
+	if tokenSymbol, exists := TokenSymbolMap[ticker.Token]; exists {
+		kite.TickSymbolMap[tokenSymbol] = ticker
+	}
+}
+
 func (k *TickerClient) onTextMessage(reader *ws.Reader) {
 	if len(reader.Message) > 0 {
 		m := &Message{}
@@ -414,7 +608,7 @@ func (k *TickerClient) onTextMessage(reader *ws.Reader) {
 		switch m.Type {
 		case "instruments_meta":
 			log.Infof("websocket : connected")
-			k.ConnectChan <- struct{}{}
+			k.signalConnect()
 		case "error":
 			k.ErrorChan <- m.Data
 		}
@@ -43,18 +43,24 @@ type TickerClient struct {
 	FullTokens                 map[uint32]bool
 	QuoteTokens                map[uint32]bool
 	LtpTokens                  map[uint32]bool
+	Depth20Tokens              map[uint32]bool
 	TokensMutex                sync.RWMutex
 	HeartBeatIntervalInSeconds float64
 	ReceiveBinaryTickers       bool
+
+	parent    *TickerClient
+	taps      []*TickerClient
+	tapsMutex sync.Mutex
+	connected bool
 }
 type LimitOrder struct {
-	Price    float64
-	Quantity uint32
-	Orders   uint32
+	Price    float64 `json:"price"`
+	Quantity uint32  `json:"quantity"`
+	Orders   uint32  `json:"orders"`
 }
 type Depth struct {
-	Buy  []LimitOrder
-	Sell []LimitOrder
+	Buy  []LimitOrder `json:"buy"`
+	Sell []LimitOrder `json:"sell"`
 }
 type KiteTicker struct {
 	TradingSymbol       string
@@ -76,6 +82,8 @@ type KiteTicker struct {
 	LastTradedTimestamp time.Time
 	ExchangeTimestamp   time.Time
 	Depth               Depth
+	ReceivedAt          time.Time
+	Segment             Segment
 }
 type Creds map[string]string
 type Kite struct {
@@ -83,13 +91,48 @@ type Kite struct {
 	TickerClients      []*TickerClient
 	TickSymbolMap      map[string]KiteTicker
 	TickSymbolMapMutex sync.RWMutex
-	Positions          []*Position
-	Pnl                float64
+	PreTradeCheck      func(order *Order) error
+	QuotePolicy        *QuotePolicy
 }
 
+// Margin is the /user/margins response. MarginUsed and MarginTotal summarise
+// the equity segment.
 type Margin struct {
 	MarginUsed  float64
 	MarginTotal float64
+	Equity      *SegmentMargin `json:"equity"`
+	Commodity   *SegmentMargin `json:"commodity"`
+}
+
+type SegmentMargin struct {
+	Enabled   bool             `json:"enabled"`
+	Net       float64          `json:"net"`
+	Available *AvailableMargin `json:"available"`
+	Utilised  *UtilisedMargin  `json:"utilised"`
+}
+
+type AvailableMargin struct {
+	AdhocMargin    float64 `json:"adhoc_margin"`
+	Cash           float64 `json:"cash"`
+	OpeningBalance float64 `json:"opening_balance"`
+	LiveBalance    float64 `json:"live_balance"`
+	Collateral     float64 `json:"collateral"`
+	IntradayPayin  float64 `json:"intraday_payin"`
+}
+
+type UtilisedMargin struct {
+	Debits           float64 `json:"debits"`
+	Exposure         float64 `json:"exposure"`
+	M2MRealised      float64 `json:"m2m_realised"`
+	M2MUnrealised    float64 `json:"m2m_unrealised"`
+	OptionPremium    float64 `json:"option_premium"`
+	Payout           float64 `json:"payout"`
+	Span             float64 `json:"span"`
+	HoldingSales     float64 `json:"holding_sales"`
+	Turnover         float64 `json:"turnover"`
+	LiquidCollateral float64 `json:"liquid_collateral"`
+	StockCollateral  float64 `json:"stock_collateral"`
+	Delivery         float64 `json:"delivery"`
 }
 
 type Order struct {
@@ -102,6 +145,9 @@ type Order struct {
 	TransactionType            string
 	Product                    string
 	OrderType                  string
+	// AllowStaleQuote prices a MARKET order off the last quote even when it
+	// is stale, for exits that must go through such as a risk square-off
+	AllowStaleQuote bool
 }
 
 type OrderPayload struct {
@@ -121,17 +167,6 @@ type OrderPayload struct {
 	TrailingStopLoss  string `query:"trailing_stoploss"`
 }
 
-type Equity struct {
-	Net       float64 `json:"net"`
-	Available *struct {
-		Cash       float64 `json:"cash"`
-		Collateral float64 `json:"collateral"`
-	} `json:"available"`
-	Utilised *struct {
-		Debits float64 `json:"debits"`
-	} `json:"utilised"`
-}
-
 type ChargesOrderRequest struct {
 	OrderId         string  `json:"order_id"`
 	Variety         string  `json:"variety"`
@@ -174,22 +209,44 @@ type Position struct {
 	DaySellQuantity   int64   `json:"day_sell_quantity"`
 	DaySellPrice      float64 `json:"day_sell_price"`
 	DaySellValue      float64 `json:"day_sell_value"`
+	PriceFallback     bool    `json:"price_fallback"`
 }
 type OptionPrice struct {
 	Strike float64
 	Price  float64
 	Type   string
 }
+type OHLC struct {
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
 type Quote struct {
-	LastPrice float64 `json:"last_price"`
-	Depth     struct {
-		Buy []struct {
-			Price float64 `json:"price"`
-		} `json:"buy"`
-		Sell []struct {
-			Price float64 `json:"price"`
-		} `json:"sell"`
-	} `json:"depth"`
+	InstrumentToken   uint32  `json:"instrument_token"`
+	Timestamp         string  `json:"timestamp"`
+	LastTradeTime     string  `json:"last_trade_time"`
+	LastPrice         float64 `json:"last_price"`
+	LastQuantity      uint32  `json:"last_quantity"`
+	BuyQuantity       uint32  `json:"buy_quantity"`
+	SellQuantity      uint32  `json:"sell_quantity"`
+	Volume            uint32  `json:"volume"`
+	AveragePrice      float64 `json:"average_price"`
+	OI                uint32  `json:"oi"`
+	OIDayHigh         uint32  `json:"oi_day_high"`
+	OIDayLow          uint32  `json:"oi_day_low"`
+	NetChange         float64 `json:"net_change"`
+	LowerCircuitLimit float64 `json:"lower_circuit_limit"`
+	UpperCircuitLimit float64 `json:"upper_circuit_limit"`
+	OHLC              OHLC    `json:"ohlc"`
+	Depth             Depth   `json:"depth"`
+
+	// Source is websocket or rest, Age the seconds since a websocket tick
+	// was received and Stale is set when that tick is older than the
+	// QuotePolicy allows
+	Source string  `json:"source"`
+	Age    float64 `json:"age"`
+	Stale  bool    `json:"stale"`
 }
 type QuoteResponsePayload struct {
 	Status    string            `json:"error"`
@@ -236,10 +293,36 @@ type OrderStatus struct {
 	Guid                    string  `json:"guid"`
 }
 
+// BrokerCharges is the charges of one order as returned by /charges/orders
 type BrokerCharges struct {
-	Charges *struct {
-		Total float64 `json:"total"`
-	} `json:"charges"`
+	OrderId         string            `json:"order_id"`
+	TransactionType string            `json:"transaction_type"`
+	TradingSymbol   string            `json:"tradingsymbol"`
+	Exchange        string            `json:"exchange"`
+	Variety         string            `json:"variety"`
+	Product         string            `json:"product"`
+	OrderType       string            `json:"order_type"`
+	Quantity        uint32            `json:"quantity"`
+	Price           float64           `json:"price"`
+	Charges         *ChargesBreakdown `json:"charges"`
+}
+
+type ChargesBreakdown struct {
+	TransactionTax         float64 `json:"transaction_tax"`
+	TransactionTaxType     string  `json:"transaction_tax_type"`
+	ExchangeTurnoverCharge float64 `json:"exchange_turnover_charge"`
+	SebiTurnoverCharge     float64 `json:"sebi_turnover_charge"`
+	Brokerage              float64 `json:"brokerage"`
+	StampDuty              float64 `json:"stamp_duty"`
+	GST                    *GST    `json:"gst"`
+	Total                  float64 `json:"total"`
+}
+
+type GST struct {
+	IGST  float64 `json:"igst"`
+	CGST  float64 `json:"cgst"`
+	SGST  float64 `json:"sgst"`
+	Total float64 `json:"total"`
 }
 
 type BrokerChargesPayload struct {
@@ -281,22 +364,29 @@ type CandlesResponsePayload struct {
 }
 
 type PositionResponsePayload struct {
-	Status    string `json:"error"`
-	Message   string `json:"message"`
-	ErrorType string `json:"error_type"`
-	Data      *struct {
-		Net []*Position `json:"net"`
-		Day []*Position `json:"day"`
-	} `json:"data"`
+	Status    string         `json:"error"`
+	Message   string         `json:"message"`
+	ErrorType string         `json:"error_type"`
+	Data      *PositionsData `json:"data"`
+}
+
+type PositionsData struct {
+	Net []*Position `json:"net"`
+	Day []*Position `json:"day"`
 }
 
 type MarginResponsePayload struct {
-	Status    string `json:"error"`
-	Message   string `json:"message"`
-	ErrorType string `json:"error_type"`
-	Data      *struct {
-		Equity *Equity `json:"equity"`
-	} `json:"data"`
+	Status    string  `json:"error"`
+	Message   string  `json:"message"`
+	ErrorType string  `json:"error_type"`
+	Data      *Margin `json:"data"`
+}
+
+type SegmentMarginResponsePayload struct {
+	Status    string         `json:"error"`
+	Message   string         `json:"message"`
+	ErrorType string         `json:"error_type"`
+	Data      *SegmentMargin `json:"data"`
 }
 
 type LoginPayload struct {
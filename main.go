@@ -5,21 +5,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math"
 	"os"
-	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/souvik131/kite-go-library/engine"
+	"github.com/souvik131/kite-go-library/greeks"
+	"github.com/souvik131/kite-go-library/history"
+	"github.com/souvik131/kite-go-library/indicators"
 	"github.com/souvik131/kite-go-library/kite"
+	"github.com/souvik131/kite-go-library/optionchain"
+	"github.com/souvik131/kite-go-library/paper"
+	"github.com/souvik131/kite-go-library/risk"
 )
 
 var kiteClient *kite.Kite = &kite.Kite{}
 
+// broker serves every tool; it is the live Kite session unless paper
+// trading is enabled
+var broker kite.Broker = kiteClient
+
+// candleStore caches historical candles on disk when TA_CANDLE_CACHE is set
+var candleStore *history.Store
+
+// chainService serves live option chains once the first one is requested
+var chainService *optionchain.Service
+var chainOnce sync.Once
+
+// helperTicker is the websocket shared by the guardian, the paper broker and
+// the option chain, each streaming on a tap of it, so together with the
+// engine's the server holds two of the three connections Kite allows
+var helperTicker *kite.TickerClient
+var helperErr error
+var helperOnce sync.Once
+
 func main() {
 	// Load environment variables
 	if os.Getenv("TA_ID") == "" {
@@ -39,6 +63,13 @@ func main() {
 		return
 	}
 	go engine.Write(&ctx, kiteClient)
+	if os.Getenv("TA_LOGINTYPE") == "PAPER" {
+		startPaperBroker(&ctx)
+	}
+	startGuardian(&ctx)
+	if dir := os.Getenv("TA_CANDLE_CACHE"); dir != "" {
+		candleStore = history.NewStore(dir, kiteClient)
+	}
 	<-time.After(time.Second * 5)
 	registerKiteTools(&ctx, srv)
 
@@ -49,19 +80,115 @@ func main() {
 	}
 }
 
+// tapHelperTicker returns a tap of the shared helper websocket, opening it on
+// first use
+func tapHelperTicker(ctx *context.Context) (*kite.TickerClient, error) {
+	helperOnce.Do(func() {
+		helperTicker, helperErr = kiteClient.GetWebSocketClient(ctx)
+		if helperErr == nil {
+			go helperTicker.Serve(ctx)
+		}
+	})
+	if helperErr != nil {
+		return nil, helperErr
+	}
+	return helperTicker.Tap(), nil
+}
+
+// startGuardian runs the MTM guardian on the helper websocket when a stop
+// loss or target is configured
+func startGuardian(ctx *context.Context) {
+	stopLoss, _ := strconv.ParseFloat(os.Getenv("TA_MTM_STOPLOSS"), 64)
+	target, _ := strconv.ParseFloat(os.Getenv("TA_MTM_TARGET"), 64)
+	if stopLoss == 0 && target == 0 {
+		return
+	}
+
+	ticker, err := tapHelperTicker(ctx)
+	if err != nil {
+		log.Printf("failed to start mtm guardian: %v", err)
+		return
+	}
+
+	guardian := risk.NewGuardian(broker, ticker, stopLoss, target)
+	go guardian.Run(ctx)
+	log.Printf("MTM guardian started with stop loss %v and target %v", stopLoss, target)
+}
+
+// optionChainService returns the option chain service, starting it on the
+// helper websocket when the first chain is requested and falling back to REST
+// quotes when the websocket cannot be opened
+func optionChainService(ctx *context.Context) *optionchain.Service {
+	chainOnce.Do(func() {
+		ticker, err := tapHelperTicker(ctx)
+		if err != nil {
+			log.Printf("option chain will quote over rest: %v", err)
+			ticker = nil
+		}
+		chainService = optionchain.NewService(broker, ticker)
+		go chainService.Run(ctx)
+	})
+	return chainService
+}
+
+// startPaperBroker routes all order and portfolio tools to a simulated broker
+// that fills against the live feed
+func startPaperBroker(ctx *context.Context) {
+	capital, err := strconv.ParseFloat(os.Getenv("TA_PAPER_CAPITAL"), 64)
+	if err != nil {
+		capital = paper.DefaultCapital
+	}
+
+	ticker, err := tapHelperTicker(ctx)
+	if err != nil {
+		log.Printf("paper broker will only fill from the engine feed: %v", err)
+		ticker = nil
+	}
+
+	paperBroker := paper.NewBroker(kiteClient, ticker, capital)
+	if path := os.Getenv("TA_FEE_TABLES"); path != "" {
+		tables, err := kite.LoadFeeTables(path)
+		if err != nil {
+			log.Printf("using default fee tables: %v", err)
+		} else {
+			paperBroker.Calculator = kite.NewChargesCalculator(tables)
+		}
+	}
+	go paperBroker.Run(ctx)
+	broker = paperBroker
+	log.Printf("Paper trading enabled with capital %v", capital)
+}
+
+// getHistoricalData serves candles from the candle cache when one is set
+func getHistoricalData(ctx *context.Context, exchange string, tradingSymbol string, interval kite.Interval, from time.Time, to time.Time, continuous bool) ([]*kite.Candle, error) {
+	if candleStore != nil {
+		return candleStore.GetHistoricalData(ctx, exchange, tradingSymbol, interval, from, to, continuous)
+	}
+	return broker.GetHistoricalData(ctx, exchange, tradingSymbol, interval, from, to, continuous)
+}
+
 func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 	// Get user ID for tool naming
 	userID := os.Getenv("TA_ID")
 	if userID == "" {
 		userID = "default"
 	}
+	// Services a tool starts on first use outlive its request
+	serverCtx := ctx
 
 	// Get Margin tool
 	marginTool := mcp.NewTool(fmt.Sprintf("kite_get_margin_%s", userID),
-		mcp.WithDescription(fmt.Sprintf("Get account margin details for user %s", userID)),
+		mcp.WithDescription(fmt.Sprintf("Get available and utilised margin across equity and commodity segments for user %s", userID)),
+		mcp.WithString("segment", mcp.Description("Segment to return (optional, returns both if omitted)"), mcp.Enum("equity", "commodity")),
 	)
 	srv.AddTool(marginTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		margin, err := kiteClient.GetMargin(&ctx)
+		var margin interface{}
+		var err error
+		if segment := request.GetString("segment", ""); segment != "" {
+			margin, err = broker.GetSegmentMargin(&ctx, segment)
+		} else {
+			margin, err = broker.GetMargin(&ctx)
+		}
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get margin: %v", err)), nil
 		}
@@ -72,15 +199,15 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 
 	// Get Positions tool
 	positionsTool := mcp.NewTool(fmt.Sprintf("kite_get_positions_%s", userID),
-		mcp.WithDescription(fmt.Sprintf("Get current positions for user %s", userID)),
+		mcp.WithDescription(fmt.Sprintf("Get current net and day positions with per-position P&L for user %s", userID)),
 	)
 	srv.AddTool(positionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		err := kiteClient.GetPositions(&ctx)
+		positions, err := broker.GetPositions(&ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get positions: %v", err)), nil
 		}
 
-		resultBytes, _ := json.Marshal(kiteClient.Positions)
+		resultBytes, _ := json.Marshal(positions)
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
 
@@ -89,7 +216,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		mcp.WithDescription(fmt.Sprintf("Get all orders for user %s", userID)),
 	)
 	srv.AddTool(ordersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		orders, err := kiteClient.GetOrders(&ctx)
+		orders, err := broker.GetOrders(&ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get orders: %v", err)), nil
 		}
@@ -109,7 +236,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 			return mcp.NewToolResultError("order_id is required"), nil
 		}
 
-		history, err := kiteClient.GetOrderHistory(&ctx, orderID)
+		history, err := broker.GetOrderHistory(&ctx, orderID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get order history: %v", err)), nil
 		}
@@ -118,16 +245,63 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
 
+	// Get Trades tool
+	tradesTool := mcp.NewTool(fmt.Sprintf("kite_get_trades_%s", userID),
+		mcp.WithDescription(fmt.Sprintf("Get executed trades (fills) for the day, or for a single order, for user %s", userID)),
+		mcp.WithString("order_id", mcp.Description("Order ID to get fills for (optional, returns all trades for the day if omitted)")),
+	)
+	srv.AddTool(tradesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		orderID := request.GetString("order_id", "")
+
+		var trades []*kite.Trade
+		var err error
+		if orderID == "" {
+			trades, err = broker.GetTrades(&ctx)
+		} else {
+			trades, err = broker.GetOrderTrades(&ctx, orderID)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get trades: %v", err)), nil
+		}
+
+		resultBytes, _ := json.Marshal(trades)
+		return mcp.NewToolResultText(string(resultBytes)), nil
+	})
+
 	// Get Quote tool
 	quoteTool := mcp.NewTool(fmt.Sprintf("kite_get_quote_%s", userID),
-		mcp.WithDescription(fmt.Sprintf("Get quote for a trading symbol for user %s", userID)),
-		mcp.WithString("exchange", mcp.Description("Exchange (e.g., NSE, BSE, NFO, BFO)"), mcp.Required()),
-		mcp.WithString("trading_symbol", mcp.Description("Trading symbol (e.g., RELIANCE, NIFTY24DEC24000CE)"), mcp.Required()),
+		mcp.WithDescription(fmt.Sprintf("Get quotes for one trading symbol or a list of instruments for user %s", userID)),
+		mcp.WithString("exchange", mcp.Description("Exchange (e.g., NSE, BSE, NFO, BFO)")),
+		mcp.WithString("trading_symbol", mcp.Description("Trading symbol (e.g., RELIANCE, NIFTY24DEC24000CE)")),
+		mcp.WithArray("instruments", mcp.Description("Instruments as EXCHANGE:SYMBOL (e.g., NSE:RELIANCE); returns a map keyed by instrument"), mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithString("mode", mcp.Description("Quote mode for instruments: full, ohlc or ltp (default full)")),
 	)
 	srv.AddTool(quoteTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		instruments := request.GetStringSlice("instruments", nil)
+		if len(instruments) > 0 {
+			var quotes interface{}
+			var err error
+			switch request.GetString("mode", "full") {
+			case "full":
+				quotes, err = broker.GetQuotes(&ctx, instruments)
+			case "ohlc":
+				quotes, err = broker.GetOHLC(&ctx, instruments)
+			case "ltp":
+				quotes, err = broker.GetLTP(&ctx, instruments)
+			default:
+				return mcp.NewToolResultError("mode must be full, ohlc or ltp"), nil
+			}
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get quotes: %v", err)), nil
+			}
+
+			resultBytes, _ := json.Marshal(quotes)
+			return mcp.NewToolResultText(string(resultBytes)), nil
+		}
+
 		exchange, err := request.RequireString("exchange")
 		if err != nil {
-			return mcp.NewToolResultError("exchange is required"), nil
+			return mcp.NewToolResultError("exchange or instruments is required"), nil
 		}
 
 		tradingSymbol, err := request.RequireString("trading_symbol")
@@ -135,7 +309,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 			return mcp.NewToolResultError("trading_symbol is required"), nil
 		}
 
-		quote, err := kiteClient.GetQuote(&ctx, exchange, tradingSymbol)
+		quote, err := broker.GetQuote(&ctx, exchange, tradingSymbol)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get quote: %v", err)), nil
 		}
@@ -161,12 +335,17 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 			return mcp.NewToolResultError("trading_symbol is required"), nil
 		}
 
-		price, err := kiteClient.GetLastPrice(&ctx, exchange, tradingSymbol)
+		quote, err := broker.GetQuote(&ctx, exchange, tradingSymbol)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get last price: %v", err)), nil
 		}
 
-		result := map[string]interface{}{"last_price": price}
+		result := map[string]interface{}{
+			"last_price": quote.LastPrice,
+			"source":     quote.Source,
+			"age":        quote.Age,
+			"stale":      quote.Stale,
+		}
 		resultBytes, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
@@ -232,7 +411,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		order.MarketProtectionPercentage = request.GetFloat("market_protection_percentage", 0)
 		order.TickSize = request.GetFloat("tick_size", 0.05)
 
-		orderID, err := kiteClient.PlaceOrder(&ctx, order)
+		orderID, err := broker.PlaceOrder(&ctx, order)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to place order: %v", err)), nil
 		}
@@ -253,7 +432,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 			return mcp.NewToolResultError("order_id is required"), nil
 		}
 
-		err = kiteClient.CancelOrder(&ctx, orderID)
+		err = broker.CancelOrder(&ctx, orderID)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to cancel order: %v", err)), nil
 		}
@@ -263,17 +442,69 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
 
+	// Convert Position tool
+	convertPositionTool := mcp.NewTool(fmt.Sprintf("kite_convert_position_%s", userID),
+		mcp.WithDescription(fmt.Sprintf("Convert an open position to another product, e.g. MIS to NRML/CNC to carry it overnight, for user %s", userID)),
+		mcp.WithString("exchange", mcp.Description("Exchange (NSE, BSE, NFO, etc.)"), mcp.Required()),
+		mcp.WithString("trading_symbol", mcp.Description("Trading symbol"), mcp.Required()),
+		mcp.WithString("old_product", mcp.Description("Current product of the position"), mcp.Enum("MIS", "CNC", "NRML"), mcp.Required()),
+		mcp.WithString("new_product", mcp.Description("Product to convert to"), mcp.Enum("MIS", "CNC", "NRML"), mcp.Required()),
+		mcp.WithNumber("quantity", mcp.Description("Quantity to convert, at most the open net quantity"), mcp.Required()),
+	)
+	srv.AddTool(convertPositionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		exchange, err := request.RequireString("exchange")
+		if err != nil {
+			return mcp.NewToolResultError("exchange is required"), nil
+		}
+
+		tradingSymbol, err := request.RequireString("trading_symbol")
+		if err != nil {
+			return mcp.NewToolResultError("trading_symbol is required"), nil
+		}
+
+		oldProduct, err := request.RequireString("old_product")
+		if err != nil {
+			return mcp.NewToolResultError("old_product is required"), nil
+		}
+
+		newProduct, err := request.RequireString("new_product")
+		if err != nil {
+			return mcp.NewToolResultError("new_product is required"), nil
+		}
+
+		quantity, err := request.RequireFloat("quantity")
+		if err != nil || quantity <= 0 {
+			return mcp.NewToolResultError("quantity must be a positive number"), nil
+		}
+
+		position := &kite.Position{Exchange: exchange, TradingSymbol: tradingSymbol, Product: oldProduct}
+		err = broker.ConvertPosition(&ctx, position, oldProduct, newProduct, uint32(quantity))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to convert position: %v", err)), nil
+		}
+
+		result := map[string]interface{}{"status": "success", "message": "Position converted successfully"}
+		resultBytes, _ := json.Marshal(result)
+		return mcp.NewToolResultText(string(resultBytes)), nil
+	})
+
 	// Get Charges tool
 	chargesTool := mcp.NewTool(fmt.Sprintf("kite_get_charges_%s", userID),
-		mcp.WithDescription(fmt.Sprintf("Get brokerage charges for user %s", userID)),
+		mcp.WithDescription(fmt.Sprintf("Get total charges and the per-order breakdown (brokerage, STT/CTT, exchange turnover, SEBI, GST, stamp duty) of completed orders for user %s", userID)),
 	)
 	srv.AddTool(chargesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		charges, err := kiteClient.GetCharges(&ctx)
+		orderCharges, err := broker.GetOrderCharges(&ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get charges: %v", err)), nil
 		}
 
-		result := map[string]interface{}{"charges": charges}
+		charges := 0.0
+		for _, c := range orderCharges {
+			if c.Charges != nil {
+				charges += c.Charges.Total
+			}
+		}
+		result := map[string]interface{}{"charges": charges, "orders": orderCharges}
 		resultBytes, _ := json.Marshal(result)
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
@@ -334,18 +565,29 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		mcp.WithDescription(fmt.Sprintf("Get historical candle data for an instrument for user %s", userID)),
 		mcp.WithString("exchange", mcp.Description("Exchange (e.g., NSE, BSE, NFO, BFO)"), mcp.Required()),
 		mcp.WithString("trading_symbol", mcp.Description("Trading symbol"), mcp.Required()),
-		mcp.WithString("interval", mcp.Description("Candle interval (minute, 3minute, 5minute, 15minute, 30minute, 60minute, day)"), mcp.Required()),
-		mcp.WithString("from_date", mcp.Description("Start date (YYYY-MM-DD)"), mcp.Required()),
-		mcp.WithString("to_date", mcp.Description("End date (YYYY-MM-DD)"), mcp.Required()),
+		mcp.WithString("interval", mcp.Description("Candle interval (minute, 3minute, 5minute, 10minute, 15minute, 30minute, 60minute, day)"), mcp.Required()),
+		mcp.WithString("from_date", mcp.Description("Start date (YYYY-MM-DD or YYYY-MM-DD HH:MM:SS, IST)"), mcp.Required()),
+		mcp.WithString("to_date", mcp.Description("End date (YYYY-MM-DD or YYYY-MM-DD HH:MM:SS, IST)"), mcp.Required()),
+		mcp.WithBoolean("continuous", mcp.Description("Stitch expired futures contracts into one series (optional)")),
 	)
 	srv.AddTool(historicalDataTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		exchange, _ := request.RequireString("exchange")
 		tradingSymbol, _ := request.RequireString("trading_symbol")
-		interval, _ := request.RequireString("interval")
+		intervalName, _ := request.RequireString("interval")
 		fromDate, _ := request.RequireString("from_date")
 		toDate, _ := request.RequireString("to_date")
+		continuous := request.GetBool("continuous", false)
 
-		candles, err := kiteClient.GetHistoricalData(&ctx, exchange, tradingSymbol, interval, fromDate, toDate)
+		interval, err := kite.ParseInterval(intervalName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid interval %s", intervalName)), nil
+		}
+		from, to, err := kite.ParseHistoricalRange(fromDate, toDate)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid date range: %v", err)), nil
+		}
+
+		candles, err := getHistoricalData(&ctx, exchange, tradingSymbol, interval, from, to, continuous)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get historical data: %v", err)), nil
 		}
@@ -354,6 +596,59 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
 
+	// Indicators tool
+	indicatorsTool := mcp.NewTool(fmt.Sprintf("kite_get_indicators_%s", userID),
+		mcp.WithDescription(fmt.Sprintf("Compute a technical indicator over historical candles of an instrument for user %s", userID)),
+		mcp.WithString("exchange", mcp.Description("Exchange (e.g., NSE, BSE, NFO, BFO)"), mcp.Required()),
+		mcp.WithString("trading_symbol", mcp.Description("Trading symbol"), mcp.Required()),
+		mcp.WithString("interval", mcp.Description("Candle interval (minute, 3minute, 5minute, 10minute, 15minute, 30minute, 60minute, day)"), mcp.Required()),
+		mcp.WithString("indicator", mcp.Description("Indicator"), mcp.Enum(indicators.Names...), mcp.Required()),
+		mcp.WithString("from_date", mcp.Description("Start date (YYYY-MM-DD or YYYY-MM-DD HH:MM:SS, IST)"), mcp.Required()),
+		mcp.WithString("to_date", mcp.Description("End date (YYYY-MM-DD or YYYY-MM-DD HH:MM:SS, IST)"), mcp.Required()),
+		mcp.WithNumber("period", mcp.Description("Lookback period (optional)")),
+		mcp.WithNumber("fast", mcp.Description("MACD fast period (optional, default 12)")),
+		mcp.WithNumber("slow", mcp.Description("MACD slow period (optional, default 26)")),
+		mcp.WithNumber("signal", mcp.Description("MACD signal period (optional, default 9)")),
+		mcp.WithNumber("width", mcp.Description("Bollinger band width in standard deviations (optional, default 2)")),
+		mcp.WithNumber("multiplier", mcp.Description("Supertrend ATR multiplier (optional, default 3)")),
+	)
+	srv.AddTool(indicatorsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		exchange, _ := request.RequireString("exchange")
+		tradingSymbol, _ := request.RequireString("trading_symbol")
+		intervalName, _ := request.RequireString("interval")
+		name, _ := request.RequireString("indicator")
+		fromDate, _ := request.RequireString("from_date")
+		toDate, _ := request.RequireString("to_date")
+
+		interval, err := kite.ParseInterval(intervalName)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid interval %s", intervalName)), nil
+		}
+		from, to, err := kite.ParseHistoricalRange(fromDate, toDate)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid date range: %v", err)), nil
+		}
+
+		candles, err := getHistoricalData(&ctx, exchange, tradingSymbol, interval, from, to, false)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get historical data: %v", err)), nil
+		}
+		points, err := indicators.Compute(name, candles, indicators.Params{
+			Period:     int(request.GetFloat("period", 0)),
+			Fast:       int(request.GetFloat("fast", 0)),
+			Slow:       int(request.GetFloat("slow", 0)),
+			Signal:     int(request.GetFloat("signal", 0)),
+			Width:      request.GetFloat("width", 0),
+			Multiplier: request.GetFloat("multiplier", 0),
+		})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to compute %s: %v", name, err)), nil
+		}
+
+		resultBytes, _ := json.Marshal(points)
+		return mcp.NewToolResultText(string(resultBytes)), nil
+	})
+
 	// Modify Order tool
 	modifyOrderTool := mcp.NewTool(fmt.Sprintf("kite_modify_order_%s", userID),
 		mcp.WithDescription(fmt.Sprintf("Modify an existing order for user %s", userID)),
@@ -383,7 +678,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		order.MarketProtectionPercentage = request.GetFloat("market_protection_percentage", 0)
 		order.TickSize = request.GetFloat("tick_size", 0.05)
 
-		err := kiteClient.ModifyOrder(&ctx, orderID, order)
+		err := broker.ModifyOrder(&ctx, orderID, order)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to modify order: %v", err)), nil
 		}
@@ -405,17 +700,34 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		expiry := request.GetString("expiry", "")
 		strikeRange := int(request.GetFloat("strike_range", 10))
 
-		optionChain := getOptionChain(underlying, expiry, strikeRange)
+		optionChain, err := optionChainService(serverCtx).Chain(&ctx, underlying, expiry, strikeRange)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get option chain: %v", err)), nil
+		}
 		resultBytes, _ := json.Marshal(optionChain)
 		return mcp.NewToolResultText(string(resultBytes)), nil
 	})
 
+	// Get Portfolio Greeks tool
+	portfolioGreeksTool := mcp.NewTool(fmt.Sprintf("kite_get_portfolio_greeks_%s", userID),
+		mcp.WithDescription(fmt.Sprintf("Get delta, gamma, vega and theta of open positions, by underlying and in total, for user %s", userID)),
+	)
+	srv.AddTool(portfolioGreeksTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		book, err := greeks.NewCalculator(broker).Compute(&ctx)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to compute greeks: %v", err)), nil
+		}
+
+		resultBytes, _ := json.Marshal(book)
+		return mcp.NewToolResultText(string(resultBytes)), nil
+	})
+
 	// Get Portfolio Holdings tool
 	holdingsTool := mcp.NewTool(fmt.Sprintf("kite_get_holdings_%s", userID),
 		mcp.WithDescription(fmt.Sprintf("Get portfolio holdings for user %s", userID)),
 	)
 	srv.AddTool(holdingsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		holdings, err := kiteClient.GetHoldings(&ctx)
+		holdings, err := broker.GetHoldings(&ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get holdings: %v", err)), nil
 		}
@@ -429,7 +741,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		mcp.WithDescription(fmt.Sprintf("Get account profile information for user %s", userID)),
 	)
 	srv.AddTool(profileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		profile, err := kiteClient.GetProfile(&ctx)
+		profile, err := broker.GetProfile(&ctx)
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to get profile: %v", err)), nil
 		}
@@ -443,7 +755,7 @@ func registerKiteTools(ctx *context.Context, srv *server.MCPServer) {
 		mcp.WithDescription(fmt.Sprintf("Fetch all available instruments for user %s (WARNING: Large dataset, use search instead)", userID)),
 	)
 	srv.AddTool(instrumentsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		instruments, err := kiteClient.FetchInstruments()
+		instruments, err := broker.FetchInstruments()
 		if err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("failed to fetch instruments: %v", err)), nil
 		}
@@ -524,138 +836,3 @@ func searchInstruments(query, exchange, instrumentType string, limit, offset int
 
 	return results
 }
-
-// Helper function to get option chain
-func getOptionChain(underlying, expiry string, strikeRange int) map[string]interface{} {
-	if kite.BrokerInstrumentTokens == nil {
-		return map[string]interface{}{"error": "instruments not loaded"}
-	}
-
-	var underlyingPrice float64 = 0
-	var targetExpiry string = expiry
-
-	// Get underlying price from WebSocket if available
-	if kiteClient.TickSymbolMap != nil {
-		kiteClient.TickSymbolMapMutex.RLock()
-		if ticker, exists := kiteClient.TickSymbolMap["NSE:"+underlying]; exists {
-			underlyingPrice = ticker.LastPrice
-		}
-		kiteClient.TickSymbolMapMutex.RUnlock()
-	}
-
-	// Find nearest expiry if not provided
-	if targetExpiry == "" {
-		targetExpiry = findNearestExpiry(underlying)
-	}
-
-	// Collect options
-	var callOptions []*kite.Instrument
-	var putOptions []*kite.Instrument
-
-	for _, instrument := range *kite.BrokerInstrumentTokens {
-		if instrument.Name == underlying &&
-			(instrument.InstrumentType == "CE" || instrument.InstrumentType == "PE") &&
-			instrument.Expiry == targetExpiry {
-
-			if instrument.InstrumentType == "CE" {
-				callOptions = append(callOptions, instrument)
-			} else {
-				putOptions = append(putOptions, instrument)
-			}
-		}
-	}
-
-	// Sort by strike price
-	sort.Slice(callOptions, func(i, j int) bool {
-		return callOptions[i].Strike < callOptions[j].Strike
-	})
-	sort.Slice(putOptions, func(i, j int) bool {
-		return putOptions[i].Strike < putOptions[j].Strike
-	})
-
-	// Filter around ATM if underlying price is available
-	if underlyingPrice > 0 && strikeRange > 0 {
-		callOptions = filterAroundATM(callOptions, underlyingPrice, strikeRange)
-		putOptions = filterAroundATM(putOptions, underlyingPrice, strikeRange)
-	}
-
-	return map[string]interface{}{
-		"underlying":       underlying,
-		"underlying_price": underlyingPrice,
-		"expiry":           targetExpiry,
-		"call_options":     callOptions,
-		"put_options":      putOptions,
-		"strike_range":     strikeRange,
-	}
-}
-
-// Helper function to find nearest expiry
-func findNearestExpiry(underlying string) string {
-	if kite.BrokerInstrumentTokens == nil {
-		return ""
-	}
-
-	expiries := make(map[string]bool)
-	for _, instrument := range *kite.BrokerInstrumentTokens {
-		if instrument.Name == underlying &&
-			(instrument.InstrumentType == "CE" || instrument.InstrumentType == "PE") &&
-			instrument.Expiry != "" {
-			expiries[instrument.Expiry] = true
-		}
-	}
-
-	// Convert to slice and sort
-	var expiryList []string
-	for expiry := range expiries {
-		expiryList = append(expiryList, expiry)
-	}
-	sort.Strings(
-expiryList)
-
-	// Return nearest future expiry
-	now := time.Now().Format("2006-01-02")
-	for _, expiry := range expiryList {
-		if expiry >= now {
-			return expiry
-		}
-	}
-
-	// If no future expiry found, return the last one
-	if len(expiryList) > 0 {
-		return expiryList[len(expiryList)-1]
-	}
-
-	return ""
-}
-
-// Helper function to filter options around ATM
-func filterAroundATM(options []*kite.Instrument, atmPrice float64, strikeRange int) []*kite.Instrument {
-	if len(options) == 0 {
-		return options
-	}
-
-	// Find ATM strike index
-	atmIndex := 0
-	minDiff := math.Abs(options[0].Strike - atmPrice)
-
-	for i, option := range options {
-		diff := math.Abs(option.Strike - atmPrice)
-		if diff < minDiff {
-			minDiff = diff
-			atmIndex = i
-		}
-	}
-
-	// Calculate range
-	start := atmIndex - strikeRange
-	end := atmIndex + strikeRange + 1
-
-	if start < 0 {
-		start = 0
-	}
-	if end > len(options) {
-		end = len(options)
-	}
-
-	return options[start:end]
-}
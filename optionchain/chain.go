@@ -0,0 +1,354 @@
+package optionchain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+	"github.com/souvik131/kite-go-library/options"
+)
+
+// DefaultSubscribeWait is how long a chain waits for the first ticks of
+// newly subscribed strikes
+const DefaultSubscribeWait = 2 * time.Second
+
+// OptionQuote is the market of one call or put
+type OptionQuote struct {
+	TradingSymbol string          `json:"tradingsymbol"`
+	Token         uint32          `json:"instrument_token"`
+	LastPrice     float64         `json:"last_price"`
+	Bid           float64         `json:"bid"`
+	BidQuantity   uint32          `json:"bid_quantity"`
+	Ask           float64         `json:"ask"`
+	AskQuantity   uint32          `json:"ask_quantity"`
+	Volume        uint32          `json:"volume"`
+	OI            uint32          `json:"oi"`
+	OIChange      int64           `json:"oi_change"`
+	ImpliedVol    float64         `json:"implied_volatility"`
+	Greeks        *options.Greeks `json:"greeks"`
+	Source        string          `json:"source"`
+	Stale         bool            `json:"stale"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// Strike holds the call and put of one strike side by side
+type Strike struct {
+	Strike float64      `json:"strike"`
+	Call   *OptionQuote `json:"call"`
+	Put    *OptionQuote `json:"put"`
+}
+
+type Chain struct {
+	Underlying      string    `json:"underlying"`
+	UnderlyingKey   string    `json:"underlying_key"`
+	UnderlyingPrice float64   `json:"underlying_price"`
+	Expiry          string    `json:"expiry"`
+	TimeToExpiry    float64   `json:"time_to_expiry"`
+	ATMStrike       float64   `json:"atm_strike"`
+	Strikes         []*Strike `json:"strikes"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Service builds live option chains. Strikes are subscribed in full mode on
+// Ticker, whose ticks are cached through Broker so its quotes see them; API
+// logins also work without a ticker as quotes then come from REST. OI change
+// is measured from the first OI the service saw for the strike that day, as
+// Kite quotes carry no previous day OI.
+type Service struct {
+	Broker        kite.Broker
+	Ticker        *kite.TickerClient
+	RiskFreeRate  float64
+	DividendYield float64
+	SubscribeWait time.Duration
+
+	mutex      sync.Mutex
+	subscribed map[uint32]bool
+	baseline   map[uint32]uint32
+	day        string
+}
+
+// NewService returns a chain service using the rates of
+// options.ConfigFromEnv. The ticker may be nil and should otherwise be a
+// dedicated client as Run drains its TickerChan.
+func NewService(broker kite.Broker, ticker *kite.TickerClient) *Service {
+	config := options.ConfigFromEnv()
+	return &Service{
+		Broker:        broker,
+		Ticker:        ticker,
+		RiskFreeRate:  config.RiskFreeRate,
+		DividendYield: config.DividendYield,
+		SubscribeWait: DefaultSubscribeWait,
+		subscribed:    map[uint32]bool{},
+		baseline:      map[uint32]uint32{},
+	}
+}
+
+// Run caches the ticker's ticks and resubscribes the strikes on reconnect
+// until the context is cancelled
+func (s *Service) Run(ctx *context.Context) {
+	if s.Ticker == nil {
+		return
+	}
+	for {
+		select {
+		case <-(*ctx).Done():
+			return
+		case <-s.Ticker.ConnectChan:
+			s.mutex.Lock()
+			tokens := []uint32{}
+			for token := range s.subscribed {
+				tokens = append(tokens, token)
+			}
+			s.mutex.Unlock()
+			s.subscribe(ctx, tokens)
+		case ticker := <-s.Ticker.TickerChan:
+			s.Broker.StoreTick(ticker)
+		}
+	}
+}
+
+// Expiries returns the option expiries of the underlying name (e.g. NIFTY),
+// earliest first
+func Expiries(name string) []string {
+	if kite.BrokerInstrumentTokens == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	expiries := []string{}
+	for _, instrument := range *kite.BrokerInstrumentTokens {
+		if instrument.Name != name || instrument.Expiry == "" || seen[instrument.Expiry] {
+			continue
+		}
+		if instrument.InstrumentType == "CE" || instrument.InstrumentType == "PE" {
+			seen[instrument.Expiry] = true
+			expiries = append(expiries, instrument.Expiry)
+		}
+	}
+	sort.Strings(expiries)
+	return expiries
+}
+
+// NearestExpiry returns the first expiry on or after today, or the last one
+// when all have passed
+func NearestExpiry(name string, now time.Time) string {
+	expiries := Expiries(name)
+	today := now.In(kite.IST).Format(kite.YYYYMMDD)
+	for _, expiry := range expiries {
+		if expiry >= today {
+			return expiry
+		}
+	}
+	if len(expiries) > 0 {
+		return expiries[len(expiries)-1]
+	}
+	return ""
+}
+
+// Chain returns the chain of the underlying name for the expiry, the nearest
+// expiry when empty, limited to strikeRange strikes either side of the ATM
+// strike (all strikes when zero)
+func (s *Service) Chain(ctx *context.Context, name string, expiry string, strikeRange int) (*Chain, error) {
+	if kite.BrokerInstrumentTokens == nil {
+		return nil, errors.New("instruments_not_loaded")
+	}
+	now := clock.Now()
+	if expiry == "" {
+		expiry = NearestExpiry(name, now)
+	}
+
+	strikes := map[float64]*Strike{}
+	contracts := map[string]*kite.Instrument{}
+	for key, instrument := range *kite.BrokerInstrumentTokens {
+		if instrument.Name != name || instrument.Expiry != expiry {
+			continue
+		}
+		if instrument.InstrumentType != "CE" && instrument.InstrumentType != "PE" {
+			continue
+		}
+		contracts[key] = instrument
+		if _, ok := strikes[instrument.Strike]; !ok {
+			strikes[instrument.Strike] = &Strike{Strike: instrument.Strike}
+		}
+	}
+	if len(contracts) == 0 {
+		return nil, errors.New("option_chain_not_found")
+	}
+
+	chain := &Chain{Underlying: name, Expiry: expiry, Strikes: []*Strike{}, UpdatedAt: now}
+	for _, instrument := range contracts {
+		chain.UnderlyingKey = kite.UnderlyingOf(instrument)
+		chain.TimeToExpiry, _ = options.TimeToExpiry(instrument, now)
+		break
+	}
+	for _, strike := range strikes {
+		chain.Strikes = append(chain.Strikes, strike)
+	}
+	sort.Slice(chain.Strikes, func(i, j int) bool {
+		return chain.Strikes[i].Strike < chain.Strikes[j].Strike
+	})
+
+	// Price the underlying first so only the strikes around ATM are quoted
+	underlying, err := s.quotes(ctx, []string{chain.UnderlyingKey})
+	if err != nil {
+		return nil, err
+	}
+	quote, ok := underlying[chain.UnderlyingKey]
+	if !ok || quote.LastPrice <= 0 {
+		return nil, errors.New("underlying_price_unavailable")
+	}
+	chain.UnderlyingPrice = quote.LastPrice
+	chain.Strikes, chain.ATMStrike = aroundATM(chain.Strikes, quote.LastPrice, strikeRange)
+
+	selected := map[float64]bool{}
+	for _, strike := range chain.Strikes {
+		selected[strike.Strike] = true
+	}
+	keys := []string{}
+	for key, instrument := range contracts {
+		if selected[instrument.Strike] {
+			keys = append(keys, key)
+		}
+	}
+	quotes, err := s.quotes(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resetBaseline(now)
+	for _, key := range keys {
+		instrument := contracts[key]
+		option := s.optionQuote(instrument, quotes[key], chain, now)
+		strike := strikes[instrument.Strike]
+		if instrument.InstrumentType == "CE" {
+			strike.Call = option
+		} else {
+			strike.Put = option
+		}
+	}
+	return chain, nil
+}
+
+// quotes subscribes the keys on the ticker and returns their quotes,
+// waiting up to SubscribeWait for the first ticks of new subscriptions
+func (s *Service) quotes(ctx *context.Context, keys []string) (map[string]*kite.Quote, error) {
+	fresh := []uint32{}
+	s.mutex.Lock()
+	for _, key := range keys {
+		instrument, ok := (*kite.BrokerInstrumentTokens)[key]
+		if ok && !s.subscribed[instrument.Token] {
+			s.subscribed[instrument.Token] = true
+			fresh = append(fresh, instrument.Token)
+		}
+	}
+	s.mutex.Unlock()
+	if s.Ticker == nil || len(fresh) == 0 {
+		return s.Broker.GetQuotes(ctx, keys)
+	}
+
+	s.subscribe(ctx, fresh)
+	deadline := time.Now().Add(s.SubscribeWait)
+	for {
+		quotes, err := s.Broker.GetQuotes(ctx, keys)
+		if err != nil || len(quotes) >= len(keys) || time.Now().After(deadline) {
+			return quotes, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (s *Service) subscribe(ctx *context.Context, tokens []uint32) {
+	if s.Ticker == nil || len(tokens) == 0 {
+		return
+	}
+	err := s.Ticker.SubscribeFull(ctx, tokens)
+	if err != nil {
+		log.Errorf("optionchain : failed to subscribe -> %v", err)
+	}
+}
+
+func (s *Service) optionQuote(instrument *kite.Instrument, quote *kite.Quote, chain *Chain, now time.Time) *OptionQuote {
+	option := &OptionQuote{
+		TradingSymbol: instrument.TradingSymbol,
+		Token:         instrument.Token,
+		Greeks:        &options.Greeks{},
+	}
+	if quote == nil {
+		option.Error = "quote_unavailable"
+		return option
+	}
+	option.LastPrice = quote.LastPrice
+	option.Volume = quote.Volume
+	option.OI = quote.OI
+	option.Source = quote.Source
+	option.Stale = quote.Stale
+	if len(quote.Depth.Buy) > 0 {
+		option.Bid = quote.Depth.Buy[0].Price
+		option.BidQuantity = quote.Depth.Buy[0].Quantity
+	}
+	if len(quote.Depth.Sell) > 0 {
+		option.Ask = quote.Depth.Sell[0].Price
+		option.AskQuantity = quote.Depth.Sell[0].Quantity
+	}
+
+	if baseline, ok := s.baseline[instrument.Token]; ok {
+		option.OIChange = int64(quote.OI) - int64(baseline)
+	} else if quote.OI > 0 {
+		s.baseline[instrument.Token] = quote.OI
+	}
+
+	config := &options.Config{RiskFreeRate: s.RiskFreeRate, DividendYield: s.DividendYield}
+	analysis, err := config.Analyze(instrument, quote.LastPrice, chain.UnderlyingPrice, now)
+	if analysis == nil {
+		option.Error = err.Error()
+		return option
+	}
+	if err != nil && analysis.TimeToExpiry > 0 {
+		option.Error = "implied_volatility_not_found"
+	}
+	option.ImpliedVol = analysis.ImpliedVol
+	option.Greeks = analysis.Greeks
+	return option
+}
+
+// resetBaseline forgets the OI baselines of the previous day
+func (s *Service) resetBaseline(now time.Time) {
+	day := now.In(kite.IST).Format(kite.YYYYMMDD)
+	if day != s.day {
+		s.day = day
+		s.baseline = map[uint32]uint32{}
+	}
+}
+
+// aroundATM returns the strikes within strikeRange of the one nearest to
+// price, with that strike
+func aroundATM(strikes []*Strike, price float64, strikeRange int) ([]*Strike, float64) {
+	if len(strikes) == 0 {
+		return strikes, 0
+	}
+	atm := 0
+	for i, strike := range strikes {
+		if math.Abs(strike.Strike-price) < math.Abs(strikes[atm].Strike-price) {
+			atm = i
+		}
+	}
+	if strikeRange <= 0 {
+		return strikes, strikes[atm].Strike
+	}
+	start := atm - strikeRange
+	end := atm + strikeRange + 1
+	if start < 0 {
+		start = 0
+	}
+	if end > len(strikes) {
+		end = len(strikes)
+	}
+	return strikes[start:end], strikes[atm].Strike
+}
@@ -0,0 +1,51 @@
+package options
+
+import (
+	"errors"
+	"time"
+
+	"github.com/souvik131/kite-go-library/calendar"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// DefaultCloseTime is the IST time of day contracts expire when the
+// calendar has no session on the expiry date
+const DefaultCloseTime = 15*time.Hour + 30*time.Minute
+
+// CloseTimes is the IST time of day contracts of exchanges that stop
+// trading before the session ends expire; currency contracts stop at 12:30
+// on expiry. Other contracts expire at the close of the exchange's last
+// calendar session on the expiry date, so MCX follows its seasonal close.
+var CloseTimes = map[string]time.Duration{
+	"CDS": 12*time.Hour + 30*time.Minute,
+	"BCD": 12*time.Hour + 30*time.Minute,
+}
+
+// ExpiryTime is when the instrument expires on its expiry date (YYYY-MM-DD)
+func ExpiryTime(instrument *kite.Instrument) (time.Time, error) {
+	if instrument.Expiry == "" {
+		return time.Time{}, errors.New("expiry_not_found")
+	}
+	date, err := time.ParseInLocation(kite.YYYYMMDD, instrument.Expiry, kite.IST)
+	if err != nil {
+		return time.Time{}, errors.New("invalid_expiry")
+	}
+	if closeTime, ok := CloseTimes[instrument.Exchange]; ok {
+		return date.Add(closeTime), nil
+	}
+	sessions, _ := calendar.Default().Sessions(instrument.Exchange, date)
+	if len(sessions) > 0 {
+		return sessions[len(sessions)-1].Close, nil
+	}
+	return date.Add(DefaultCloseTime), nil
+}
+
+// TimeToExpiry is the time in years, of 365 days, from now to the
+// instrument's expiry. It is negative once the instrument has expired.
+func TimeToExpiry(instrument *kite.Instrument, now time.Time) (float64, error) {
+	expiry, err := ExpiryTime(instrument)
+	if err != nil {
+		return 0, err
+	}
+	return expiry.Sub(now).Hours() / (24 * 365), nil
+}
@@ -0,0 +1,81 @@
+package options
+
+import (
+	"errors"
+	"math"
+)
+
+// Volatilities the solver searches between
+const (
+	MinVolatility = 0.0001
+	MaxVolatility = 5.0
+)
+
+// ImpliedVolatility solves for the Black-Scholes-Merton volatility that
+// prices the option at optionPrice
+func ImpliedVolatility(isCall bool, optionPrice float64, spot float64, strike float64, t float64, rate float64, dividend float64) (float64, error) {
+	return solve(isCall, optionPrice, spot, strike, t, rate, dividend)
+}
+
+// ImpliedVolatility76 solves for the Black-76 volatility that prices the
+// option on a future at optionPrice
+func ImpliedVolatility76(isCall bool, optionPrice float64, forward float64, strike float64, t float64, rate float64) (float64, error) {
+	return solve(isCall, optionPrice, forward, strike, t, rate, rate)
+}
+
+// solve runs Newton-Raphson on the volatility, falling back to bisection
+// whenever a step leaves the bracket known to hold the root, so it converges
+// for deep in and out of the money options where vega vanishes. Prices
+// outside the no-arbitrage bounds have no volatility and are rejected.
+func solve(isCall bool, optionPrice float64, underlying float64, strike float64, t float64, rate float64, dividend float64) (float64, error) {
+	if optionPrice <= 0 || underlying <= 0 || strike <= 0 || t <= 0 {
+		return 0, errors.New("invalid_option_inputs")
+	}
+	forward := underlying * math.Exp(-dividend*t)
+	discounted := strike * math.Exp(-rate*t)
+	lower, upper := math.Max(forward-discounted, 0), forward
+	if !isCall {
+		lower, upper = math.Max(discounted-forward, 0), discounted
+	}
+	if optionPrice <= lower {
+		return 0, errors.New("price_below_intrinsic")
+	}
+	if optionPrice >= upper {
+		return 0, errors.New("price_above_bound")
+	}
+
+	value := func(volatility float64) float64 {
+		return BlackScholes(isCall, underlying, strike, t, rate, dividend, volatility)
+	}
+	low, high := MinVolatility, MaxVolatility
+	if optionPrice < value(low) || optionPrice > value(high) {
+		return 0, errors.New("volatility_out_of_range")
+	}
+
+	// Brenner-Subrahmanyam starting point, exact at the money
+	volatility := math.Sqrt(2*math.Pi/t) * optionPrice / underlying
+	if volatility <= low || volatility >= high {
+		volatility = (low + high) / 2
+	}
+	for i := 0; i < 100; i++ {
+		diff := value(volatility) - optionPrice
+		if math.Abs(diff) < 1e-12*optionPrice {
+			return volatility, nil
+		}
+		if diff < 0 {
+			low = volatility
+		} else {
+			high = volatility
+		}
+		if high-low < 1e-12 {
+			break
+		}
+		vega := BlackScholesGreeks(isCall, underlying, strike, t, rate, dividend, volatility).Vega * 100
+		next := volatility - diff/vega
+		if vega <= 0 || math.IsNaN(next) || next <= low || next >= high {
+			next = (low + high) / 2
+		}
+		volatility = next
+	}
+	return volatility, nil
+}
@@ -0,0 +1,98 @@
+package options
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// DefaultRiskFreeRate is the annual rate used when TA_RISK_FREE_RATE is not
+// set
+const DefaultRiskFreeRate = 0.07
+
+// Pricing models
+const (
+	ModelBlackScholes = "black_scholes"
+	ModelBlack76      = "black_76"
+)
+
+// Config holds the annual risk-free rate and dividend yield, both as
+// fractions (0.07 for 7%)
+type Config struct {
+	RiskFreeRate  float64
+	DividendYield float64
+}
+
+// ConfigFromEnv reads TA_RISK_FREE_RATE and TA_DIVIDEND_YIELD
+func ConfigFromEnv() *Config {
+	config := &Config{RiskFreeRate: DefaultRiskFreeRate}
+	if rate, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("TA_RISK_FREE_RATE")), 64); err == nil {
+		config.RiskFreeRate = rate
+	}
+	if dividend, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("TA_DIVIDEND_YIELD")), 64); err == nil {
+		config.DividendYield = dividend
+	}
+	return config
+}
+
+// Analysis is the implied volatility and Greeks of one unit of an option
+type Analysis struct {
+	Model           string  `json:"model"`
+	Price           float64 `json:"price"`
+	UnderlyingPrice float64 `json:"underlying_price"`
+	Strike          float64 `json:"strike"`
+	TimeToExpiry    float64 `json:"time_to_expiry"`
+	ImpliedVol      float64 `json:"implied_volatility"`
+	Greeks          *Greeks `json:"greeks"`
+}
+
+// ModelOf returns Black-76 for options priced off a future, such as
+// commodity and currency options, and Black-Scholes otherwise
+func ModelOf(instrument *kite.Instrument) string {
+	underlying := kite.UnderlyingOf(instrument)
+	if kite.BrokerInstrumentTokens != nil {
+		if future, ok := (*kite.BrokerInstrumentTokens)[underlying]; ok && future.InstrumentType == "FUT" {
+			return ModelBlack76
+		}
+	}
+	switch instrument.Exchange {
+	case "MCX", "CDS", "BCD":
+		return ModelBlack76
+	}
+	return ModelBlackScholes
+}
+
+// Analyze implies the volatility of the option instrument from its price and
+// the price of its underlying (kite.UnderlyingOf) at now. When no volatility
+// reproduces the price the analysis still carries the intrinsic Greeks along
+// with the error.
+func (config *Config) Analyze(instrument *kite.Instrument, optionPrice float64, underlyingPrice float64, now time.Time) (*Analysis, error) {
+	if instrument.InstrumentType != "CE" && instrument.InstrumentType != "PE" {
+		return nil, errors.New("not_an_option")
+	}
+	t, err := TimeToExpiry(instrument, now)
+	if err != nil {
+		return nil, err
+	}
+
+	isCall := instrument.InstrumentType == "CE"
+	analysis := &Analysis{
+		Model:           ModelOf(instrument),
+		Price:           optionPrice,
+		UnderlyingPrice: underlyingPrice,
+		Strike:          instrument.Strike,
+		TimeToExpiry:    t,
+	}
+	if analysis.Model == ModelBlack76 {
+		analysis.ImpliedVol, err = ImpliedVolatility76(isCall, optionPrice, underlyingPrice, instrument.Strike, t, config.RiskFreeRate)
+		analysis.Greeks = Black76Greeks(isCall, underlyingPrice, instrument.Strike, t, config.RiskFreeRate, analysis.ImpliedVol)
+	} else {
+		analysis.ImpliedVol, err = ImpliedVolatility(isCall, optionPrice, underlyingPrice, instrument.Strike, t, config.RiskFreeRate, config.DividendYield)
+		analysis.Greeks = BlackScholesGreeks(isCall, underlyingPrice, instrument.Strike, t, config.RiskFreeRate, config.DividendYield, analysis.ImpliedVol)
+	}
+	return analysis, err
+}
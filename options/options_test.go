@@ -0,0 +1,155 @@
+package options
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference values from the worked examples in Hull, Options, Futures and
+// Other Derivatives, which are published to two or three decimals. Hull
+// quotes vega per unit of volatility, theta per year and rho per unit of
+// the rate, so they are scaled here to the units of Greeks.
+
+func assertClose(t *testing.T, name string, got float64, want float64, tolerance float64) {
+	t.Helper()
+	if math.IsNaN(got) || math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.6f, want %.6f", name, got, want)
+	}
+}
+
+func TestBlackScholes(t *testing.T) {
+	assertClose(t, "call", BlackScholes(true, 42, 40, 0.5, 0.1, 0, 0.2), 4.76, 0.005)
+	assertClose(t, "put", BlackScholes(false, 42, 40, 0.5, 0.1, 0, 0.2), 0.81, 0.005)
+	assertClose(t, "call with dividend", BlackScholes(true, 930, 900, 2.0/12, 0.08, 0.03, 0.2), 51.83, 0.005)
+}
+
+func TestBlack76(t *testing.T) {
+	assertClose(t, "put", Black76(false, 20, 20, 4.0/12, 0.09, 0.25), 1.12, 0.005)
+}
+
+func TestBlackScholesGreeks(t *testing.T) {
+	greeks := BlackScholesGreeks(true, 49, 50, 0.3846, 0.05, 0, 0.2)
+	assertClose(t, "delta", greeks.Delta, 0.522, 0.0005)
+	assertClose(t, "gamma", greeks.Gamma, 0.066, 0.0005)
+	assertClose(t, "vega", greeks.Vega, 12.1/100, 0.0005)
+	assertClose(t, "theta", greeks.Theta, -4.31/365, 0.00005)
+	assertClose(t, "rho", greeks.Rho, 8.91/100, 0.0005)
+}
+
+func TestBlack76Greeks(t *testing.T) {
+	// Black-76 Greeks checked against central differences of Black76
+	forward, strike, expiry, rate, volatility := 100.0, 105.0, 0.3, 0.08, 0.25
+	value := func(forward float64, t float64, rate float64, volatility float64) float64 {
+		return Black76(true, forward, strike, t, rate, volatility)
+	}
+	h := 1e-4
+	greeks := Black76Greeks(true, forward, strike, expiry, rate, volatility)
+	assertClose(t, "delta", greeks.Delta, (value(forward+h, expiry, rate, volatility)-value(forward-h, expiry, rate, volatility))/(2*h), 1e-6)
+	assertClose(t, "gamma", greeks.Gamma, (value(forward+h, expiry, rate, volatility)-2*value(forward, expiry, rate, volatility)+value(forward-h, expiry, rate, volatility))/(h*h), 1e-4)
+	assertClose(t, "vega", greeks.Vega, (value(forward, expiry, rate, volatility+h)-value(forward, expiry, rate, volatility-h))/(2*h)/100, 1e-6)
+	assertClose(t, "theta", greeks.Theta, -(value(forward, expiry+h, rate, volatility)-value(forward, expiry-h, rate, volatility))/(2*h)/365, 1e-6)
+	assertClose(t, "rho", greeks.Rho, (value(forward, expiry, rate+h, volatility)-value(forward, expiry, rate-h, volatility))/(2*h)/100, 1e-6)
+}
+
+func TestPutCallParity(t *testing.T) {
+	for _, strike := range []float64{60, 95, 100, 105, 150} {
+		spot, expiry, rate, dividend, volatility := 100.0, 0.25, 0.07, 0.02, 0.3
+		call := BlackScholes(true, spot, strike, expiry, rate, dividend, volatility)
+		put := BlackScholes(false, spot, strike, expiry, rate, dividend, volatility)
+		assertClose(t, "black scholes parity", call-put, spot*math.Exp(-dividend*expiry)-strike*math.Exp(-rate*expiry), 1e-9)
+
+		call = Black76(true, spot, strike, expiry, rate, volatility)
+		put = Black76(false, spot, strike, expiry, rate, volatility)
+		assertClose(t, "black 76 parity", call-put, (spot-strike)*math.Exp(-rate*expiry), 1e-9)
+
+		callGreeks := BlackScholesGreeks(true, spot, strike, expiry, rate, dividend, volatility)
+		putGreeks := BlackScholesGreeks(false, spot, strike, expiry, rate, dividend, volatility)
+		assertClose(t, "delta parity", callGreeks.Delta-putGreeks.Delta, math.Exp(-dividend*expiry), 1e-9)
+		assertClose(t, "gamma parity", callGreeks.Gamma, putGreeks.Gamma, 1e-12)
+		assertClose(t, "vega parity", callGreeks.Vega, putGreeks.Vega, 1e-12)
+	}
+}
+
+func TestImpliedVolatilityRoundTrip(t *testing.T) {
+	cases := []struct {
+		name       string
+		isCall     bool
+		strike     float64
+		expiry     float64
+		volatility float64
+	}{
+		{"atm call", true, 100, 0.25, 0.2},
+		{"deep itm call", true, 70, 0.5, 0.3},
+		{"deep otm call", true, 150, 0.5, 0.3},
+		{"deep itm put", false, 140, 0.5, 0.3},
+		{"deep otm put", false, 65, 0.5, 0.3},
+		{"high volatility", true, 120, 1, 1.5},
+		{"one day call", true, 101, 1.0 / 365, 0.15},
+		{"one day put", false, 99, 1.0 / 365, 0.15},
+		{"last hour call", true, 100.2, 1.0 / (365 * 24), 0.2},
+	}
+	spot, rate, dividend := 100.0, 0.07, 0.01
+	for _, c := range cases {
+		price := BlackScholes(c.isCall, spot, c.strike, c.expiry, rate, dividend, c.volatility)
+		volatility, err := ImpliedVolatility(c.isCall, price, spot, c.strike, c.expiry, rate, dividend)
+		if err != nil {
+			t.Errorf("%s: implied volatility of %.6f -> %v", c.name, price, err)
+			continue
+		}
+		assertClose(t, c.name, volatility, c.volatility, 1e-6)
+
+		price = Black76(c.isCall, spot, c.strike, c.expiry, rate, c.volatility)
+		volatility, err = ImpliedVolatility76(c.isCall, price, spot, c.strike, c.expiry, rate)
+		if err != nil {
+			t.Errorf("%s: black 76 implied volatility of %.6f -> %v", c.name, price, err)
+			continue
+		}
+		assertClose(t, c.name+" black 76", volatility, c.volatility, 1e-6)
+	}
+}
+
+func TestImpliedVolatilityBounds(t *testing.T) {
+	spot, expiry, rate := 100.0, 0.5, 0.07
+	cases := []struct {
+		name   string
+		isCall bool
+		price  float64
+		strike float64
+		want   string
+	}{
+		{"call below intrinsic", true, 20, 80, "price_below_intrinsic"},
+		{"call at discounted intrinsic", true, spot - 80*math.Exp(-rate*expiry), 80, "price_below_intrinsic"},
+		{"put below intrinsic", false, 15, 120, "price_below_intrinsic"},
+		{"call above spot", true, 101, 80, "price_above_bound"},
+		{"put above discounted strike", false, 120, 120, "price_above_bound"},
+		{"zero price", true, 0, 100, "invalid_option_inputs"},
+	}
+	for _, c := range cases {
+		_, err := ImpliedVolatility(c.isCall, c.price, spot, c.strike, expiry, rate, 0)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("%s: error %v, want %s", c.name, err, c.want)
+		}
+	}
+
+	// On a future both bounds are discounted: (F - K)e^-rT below and Fe^-rT
+	// above for a call
+	discount := math.Exp(-rate * expiry)
+	cases = []struct {
+		name   string
+		isCall bool
+		price  float64
+		strike float64
+		want   string
+	}{
+		{"call at discounted intrinsic", true, (spot - 80) * discount, 80, "price_below_intrinsic"},
+		{"put below discounted intrinsic", false, 19, 120, "price_below_intrinsic"},
+		{"call at discounted future", true, spot * discount, 80, "price_above_bound"},
+		{"put above discounted strike", false, 116, 120, "price_above_bound"},
+	}
+	for _, c := range cases {
+		_, err := ImpliedVolatility76(c.isCall, c.price, spot, c.strike, expiry, rate)
+		if err == nil || err.Error() != c.want {
+			t.Errorf("black 76 %s: error %v, want %s", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package options
+
+import (
+	"math"
+)
+
+// Greeks of one unit of an option. Vega is per volatility point, theta per
+// calendar day and rho per percentage point of the rate.
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Vega  float64 `json:"vega"`
+	Theta float64 `json:"theta"`
+	Rho   float64 `json:"rho"`
+}
+
+func normCdf(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func normPdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func d1d2(underlying float64, strike float64, t float64, carry float64, volatility float64) (float64, float64) {
+	d1 := (math.Log(underlying/strike) + (carry+volatility*volatility/2)*t) / (volatility * math.Sqrt(t))
+	return d1, d1 - volatility*math.Sqrt(t)
+}
+
+func intrinsic(isCall bool, underlying float64, strike float64) float64 {
+	if isCall {
+		return math.Max(underlying-strike, 0)
+	}
+	return math.Max(strike-underlying, 0)
+}
+
+// BlackScholes is the Black-Scholes-Merton value of a European option on a
+// spot price paying a continuous dividend yield
+func BlackScholes(isCall bool, spot float64, strike float64, t float64, rate float64, dividend float64, volatility float64) float64 {
+	if t <= 0 || volatility <= 0 {
+		return intrinsic(isCall, spot, strike)
+	}
+	d1, d2 := d1d2(spot, strike, t, rate-dividend, volatility)
+	if isCall {
+		return spot*math.Exp(-dividend*t)*normCdf(d1) - strike*math.Exp(-rate*t)*normCdf(d2)
+	}
+	return strike*math.Exp(-rate*t)*normCdf(-d2) - spot*math.Exp(-dividend*t)*normCdf(-d1)
+}
+
+// Black76 is the Black value of a European option on a futures price, as
+// for commodity and currency options
+func Black76(isCall bool, forward float64, strike float64, t float64, rate float64, volatility float64) float64 {
+	if t <= 0 || volatility <= 0 {
+		return intrinsic(isCall, forward, strike)
+	}
+	return BlackScholes(isCall, forward, strike, t, rate, rate, volatility)
+}
+
+// BlackScholesGreeks returns the Black-Scholes-Merton Greeks of one unit
+func BlackScholesGreeks(isCall bool, spot float64, strike float64, t float64, rate float64, dividend float64, volatility float64) *Greeks {
+	if t <= 0 || volatility <= 0 {
+		return intrinsicGreeks(isCall, spot, strike)
+	}
+	d1, d2 := d1d2(spot, strike, t, rate-dividend, volatility)
+	sqrtT := math.Sqrt(t)
+	discountQ := math.Exp(-dividend * t)
+	discountR := math.Exp(-rate * t)
+
+	greeks := &Greeks{
+		Gamma: discountQ * normPdf(d1) / (spot * volatility * sqrtT),
+		Vega:  spot * discountQ * normPdf(d1) * sqrtT / 100,
+	}
+	decay := -spot * discountQ * normPdf(d1) * volatility / (2 * sqrtT)
+	if isCall {
+		greeks.Delta = discountQ * normCdf(d1)
+		greeks.Theta = (decay - rate*strike*discountR*normCdf(d2) + dividend*spot*discountQ*normCdf(d1)) / 365
+		greeks.Rho = strike * t * discountR * normCdf(d2) / 100
+	} else {
+		greeks.Delta = -discountQ * normCdf(-d1)
+		greeks.Theta = (decay + rate*strike*discountR*normCdf(-d2) - dividend*spot*discountQ*normCdf(-d1)) / 365
+		greeks.Rho = -strike * t * discountR * normCdf(-d2) / 100
+	}
+	return greeks
+}
+
+// Black76Greeks returns the Black Greeks of one unit, with delta and gamma
+// taken against the futures price
+func Black76Greeks(isCall bool, forward float64, strike float64, t float64, rate float64, volatility float64) *Greeks {
+	if t <= 0 || volatility <= 0 {
+		return intrinsicGreeks(isCall, forward, strike)
+	}
+	// Black-76 is Black-Scholes with the rate as the yield of the future,
+	// except for rho where the future's yield does not move with the rate
+	greeks := BlackScholesGreeks(isCall, forward, strike, t, rate, rate, volatility)
+	greeks.Rho = -t * Black76(isCall, forward, strike, t, rate, volatility) / 100
+	return greeks
+}
+
+func intrinsicGreeks(isCall bool, underlying float64, strike float64) *Greeks {
+	delta := 0.0
+	if isCall && underlying > strike {
+		delta = 1
+	}
+	if !isCall && underlying < strike {
+		delta = -1
+	}
+	return &Greeks{Delta: delta}
+}
@@ -0,0 +1,304 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+const DefaultCapital float64 = 1000000
+
+const timestampFormat = "2006-01-02 15:04:05"
+
+// Broker simulates order execution against the live websocket feed. Market
+// data, streaming and login are served by the embedded Kite session while
+// orders, positions, margin and holdings are kept in memory.
+type Broker struct {
+	*kite.Kite
+	Ticker     *kite.TickerClient
+	Capital    float64
+	Holdings   []*kite.Holding
+	Calculator *kite.ChargesCalculator
+
+	mutex     sync.Mutex
+	sequence  int64
+	orders    map[string]*paperOrder
+	orderIds  []string
+	trades    []*kite.Trade
+	positions map[string]*kite.Position
+	charges   float64
+}
+
+var _ kite.Broker = (*Broker)(nil)
+
+type paperOrder struct {
+	status  *kite.OrderStatus
+	history []*kite.OrderStatus
+}
+
+// NewBroker returns a paper broker backed by the live session k. When ticker
+// is not nil the broker subscribes the instruments of pending orders on it
+// and drains its TickerChan.
+func NewBroker(k *kite.Kite, ticker *kite.TickerClient, capital float64) *Broker {
+	return &Broker{
+		Kite:       k,
+		Ticker:     ticker,
+		Capital:    capital,
+		Holdings:   []*kite.Holding{},
+		Calculator: kite.NewChargesCalculator(kite.DefaultFeeTables),
+		orders:     map[string]*paperOrder{},
+		orderIds:   []string{},
+		trades:     []*kite.Trade{},
+		positions:  map[string]*kite.Position{},
+	}
+}
+
+// Run matches pending orders on every tick until the context is cancelled
+func (b *Broker) Run(ctx *context.Context) {
+	var ticks chan kite.KiteTicker
+	var connect chan struct{}
+	if b.Ticker != nil {
+		ticks = b.Ticker.TickerChan
+		connect = b.Ticker.ConnectChan
+	}
+
+	sweep := time.NewTicker(time.Second)
+	defer sweep.Stop()
+
+	for {
+		select {
+		case <-(*ctx).Done():
+			return
+		case <-connect:
+			b.subscribePending(ctx)
+		case ticker := <-ticks:
+			b.StoreTick(ticker)
+			b.match()
+		case <-sweep.C:
+			b.match()
+		}
+	}
+}
+
+func (b *Broker) subscribe(ctx *context.Context, tokens []uint32) {
+	if b.Ticker == nil || len(tokens) == 0 {
+		return
+	}
+	err := b.Ticker.SubscribeFull(ctx, tokens)
+	if err != nil {
+		log.Errorf("paper : failed to subscribe %v -> %v", tokens, err)
+	}
+}
+
+func (b *Broker) subscribePending(ctx *context.Context) {
+	b.mutex.Lock()
+	tokens := []uint32{}
+	for _, o := range b.orders {
+		if isPending(o.status) {
+			tokens = append(tokens, o.status.InstrumentToken)
+		}
+	}
+	b.mutex.Unlock()
+	b.subscribe(ctx, tokens)
+}
+
+// match fills every pending order whose conditions are met by the latest tick.
+// Ticks older than the quote policy allows never fill an order.
+func (b *Broker) match() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, id := range b.orderIds {
+		o := b.orders[id]
+		if !isPending(o.status) {
+			continue
+		}
+		b.TickSymbolMapMutex.RLock()
+		ticker, ok := b.TickSymbolMap[o.status.Exchange+":"+o.status.TradingSymbol]
+		b.TickSymbolMapMutex.RUnlock()
+		if !ok || ticker.LastPrice <= 0 || !b.GetQuotePolicy().Fresh(ticker) {
+			continue
+		}
+		price, filled := b.fillPrice(o, ticker)
+		if filled {
+			b.fill(o, price)
+		}
+	}
+}
+
+// fillPrice decides whether the order executes against the ticker and at
+// what price. SL orders turn into limit orders once triggered.
+func (b *Broker) fillPrice(o *paperOrder, ticker kite.KiteTicker) (float64, bool) {
+	s := o.status
+	ltp := ticker.LastPrice
+	isBuy := s.TransactionType == "BUY"
+
+	if s.OrderState == "TRIGGER PENDING" {
+		if (isBuy && ltp < s.TriggerPrice) || (!isBuy && ltp > s.TriggerPrice) {
+			return 0, false
+		}
+		s.OrderState = "OPEN"
+		o.record()
+	}
+
+	quantity := float64(s.PendingQuantity)
+	if s.OrderType == "MARKET" {
+		if isBuy {
+			return sweep(ticker.Depth.Sell, quantity, ltp), true
+		}
+		return sweep(ticker.Depth.Buy, quantity, ltp), true
+	}
+
+	limit := s.Price
+	if isBuy {
+		if len(ticker.Depth.Sell) > 0 && ticker.Depth.Sell[0].Price > 0 && ticker.Depth.Sell[0].Price <= limit {
+			return math.Min(sweep(ticker.Depth.Sell, quantity, ltp), limit), true
+		}
+		if ltp <= limit {
+			return limit, true
+		}
+		return 0, false
+	}
+	if len(ticker.Depth.Buy) > 0 && ticker.Depth.Buy[0].Price >= limit {
+		return math.Max(sweep(ticker.Depth.Buy, quantity, ltp), limit), true
+	}
+	if ltp >= limit {
+		return limit, true
+	}
+	return 0, false
+}
+
+// sweep returns the average price of taking quantity from the depth levels,
+// filling whatever the book cannot absorb at the last level seen
+func sweep(levels []kite.LimitOrder, quantity float64, fallback float64) float64 {
+	if quantity <= 0 {
+		return fallback
+	}
+	remaining := quantity
+	value := 0.0
+	last := fallback
+	for _, level := range levels {
+		if level.Price <= 0 || level.Quantity == 0 {
+			continue
+		}
+		take := math.Min(remaining, float64(level.Quantity))
+		value += take * level.Price
+		remaining -= take
+		last = level.Price
+		if remaining <= 0 {
+			break
+		}
+	}
+	if remaining > 0 {
+		value += remaining * last
+	}
+	return value / quantity
+}
+
+func (b *Broker) fill(o *paperOrder, price float64) {
+	s := o.status
+	s.AveragePrice = price
+	s.FilledQuantity += s.PendingQuantity
+	s.PendingQuantity = 0
+	s.OrderState = "COMPLETE"
+	s.ExchangeTimestamp = clock.Now().Format(timestampFormat)
+	s.ExchangeUpdateTimestamp = s.ExchangeTimestamp
+	o.record()
+
+	b.trades = append(b.trades, &kite.Trade{
+		TradeId:           fmt.Sprintf("%v%02d", s.OrderId, len(b.trades)+1),
+		OrderId:           s.OrderId,
+		TradingSymbol:     s.TradingSymbol,
+		Exchange:          s.Exchange,
+		InstrumentToken:   s.InstrumentToken,
+		TransactionType:   s.TransactionType,
+		Product:           s.Product,
+		AveragePrice:      price,
+		Quantity:          s.FilledQuantity,
+		FillTimestamp:     s.ExchangeTimestamp,
+		OrderTimestamp:    s.OrderTimestamp,
+		ExchangeTimestamp: s.ExchangeTimestamp,
+	})
+	b.updatePosition(s, price)
+	charges, err := b.Calculator.Calculate(kite.ChargesRequestFromOrder(s))
+	if err != nil {
+		log.Errorf("paper : failed to estimate charges of %v -> %v", s.OrderId, err)
+	} else {
+		b.charges += charges.Charges.Total
+	}
+	log.Infof("paper : filled order %v %v %v x %v @ %v", s.OrderId, s.TransactionType, s.TradingSymbol, s.FilledQuantity, price)
+}
+
+func (b *Broker) updatePosition(s *kite.OrderStatus, price float64) {
+	key := s.Exchange + ":" + s.TradingSymbol + ":" + s.Product
+	position, ok := b.positions[key]
+	if !ok {
+		position = &kite.Position{
+			TradingSymbol:   s.TradingSymbol,
+			Exchange:        s.Exchange,
+			InstrumentToken: s.InstrumentToken,
+			Product:         s.Product,
+			Multiplier:      int64(kite.QuantityMultiplier(s.Exchange, s.TradingSymbol)),
+		}
+		b.positions[key] = position
+	}
+
+	value := float64(s.FilledQuantity) * float64(position.Multiplier) * price
+	addFill(position, s.TransactionType, int64(s.FilledQuantity), value)
+	position.LastPrice = price
+}
+
+// addFill books quantity and value on the buy or sell side of the position
+// and recomputes the derived prices. Values include the multiplier, as in
+// Kite's positions, while prices are per unit. Negative amounts take them
+// off again.
+func addFill(position *kite.Position, transactionType string, quantity int64, value float64) {
+	if transactionType == "BUY" {
+		position.BuyQuantity += quantity
+		position.BuyValue += value
+		position.DayBuyQuantity += quantity
+		position.DayBuyValue += value
+	} else {
+		position.SellQuantity += quantity
+		position.SellValue += value
+		position.DaySellQuantity += quantity
+		position.DaySellValue += value
+	}
+	position.BuyPrice = averagePrice(position.BuyValue, position.BuyQuantity*position.Multiplier)
+	position.SellPrice = averagePrice(position.SellValue, position.SellQuantity*position.Multiplier)
+	position.DayBuyPrice = averagePrice(position.DayBuyValue, position.DayBuyQuantity*position.Multiplier)
+	position.DaySellPrice = averagePrice(position.DaySellValue, position.DaySellQuantity*position.Multiplier)
+	position.Quantity = position.BuyQuantity - position.SellQuantity
+	position.Value = position.SellValue - position.BuyValue
+	switch {
+	case position.Quantity > 0:
+		position.AveragePrice = position.BuyPrice
+	case position.Quantity < 0:
+		position.AveragePrice = position.SellPrice
+	default:
+		position.AveragePrice = 0
+	}
+}
+
+func averagePrice(value float64, quantity int64) float64 {
+	if quantity == 0 {
+		return 0
+	}
+	return value / float64(quantity)
+}
+
+// record appends a snapshot of the current status to the order history
+func (o *paperOrder) record() {
+	snapshot := *o.status
+	o.history = append(o.history, &snapshot)
+}
+
+func isPending(s *kite.OrderStatus) bool {
+	return s.OrderState == "OPEN" || s.OrderState == "TRIGGER PENDING"
+}
@@ -0,0 +1,34 @@
+package paper
+
+import (
+	"context"
+	"errors"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// Mutual fund orders and SIPs are not simulated. These shadow the embedded
+// Kite methods so a paper session can never place a live MF order; the read
+// only MF calls still go to the live account.
+
+var ErrMFNotSimulated = errors.New("paper_mf_not_simulated")
+
+func (b *Broker) PlaceMFOrder(ctx *context.Context, order *kite.MFOrder) (string, error) {
+	return "", ErrMFNotSimulated
+}
+
+func (b *Broker) CancelMFOrder(ctx *context.Context, orderId string) error {
+	return ErrMFNotSimulated
+}
+
+func (b *Broker) PlaceMFSIP(ctx *context.Context, sip *kite.MFSIP) (string, error) {
+	return "", ErrMFNotSimulated
+}
+
+func (b *Broker) ModifyMFSIP(ctx *context.Context, sipId string, sip *kite.MFSIP) error {
+	return ErrMFNotSimulated
+}
+
+func (b *Broker) CancelMFSIP(ctx *context.Context, sipId string) error {
+	return ErrMFNotSimulated
+}
@@ -0,0 +1,241 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+func (b *Broker) PlaceOrder(ctx *context.Context, order *kite.Order) (string, error) {
+	if b.PreTradeCheck != nil {
+		if err := b.PreTradeCheck(order); err != nil {
+			return "", err
+		}
+	}
+
+	if kite.BrokerInstrumentTokens == nil {
+		return "", errors.New("instruments_not_loaded")
+	}
+	symbolKey := order.Exchange + ":" + order.TradingSymbol
+	instrument, exists := (*kite.BrokerInstrumentTokens)[symbolKey]
+	if !exists {
+		return "", fmt.Errorf("instrument %s not found", symbolKey)
+	}
+
+	now := clock.Now().Format(timestampFormat)
+	status := &kite.OrderStatus{
+		PlacedBy:        "PAPER",
+		OrderTimestamp:  now,
+		Variety:         "regular",
+		Exchange:        order.Exchange,
+		TradingSymbol:   order.TradingSymbol,
+		InstrumentToken: instrument.Token,
+		TransactionType: order.TransactionType,
+		Validity:        "DAY",
+		Product:         order.Product,
+	}
+	err := applyOrder(status, order, instrument)
+	if err != nil {
+		return "", err
+	}
+	err = b.checkTrigger(status)
+	if err != nil {
+		return "", err
+	}
+
+	b.mutex.Lock()
+	b.sequence++
+	status.OrderId = fmt.Sprintf("PAPER%d%06d", clock.Now().Unix(), b.sequence)
+	o := &paperOrder{status: status}
+	o.record()
+	b.orders[status.OrderId] = o
+	b.orderIds = append(b.orderIds, status.OrderId)
+	b.mutex.Unlock()
+
+	log.Infof("paper : placed order %v : %+v", status.OrderId, order)
+	b.subscribe(ctx, []uint32{instrument.Token})
+	b.match()
+	return status.OrderId, nil
+}
+
+func (b *Broker) ModifyOrder(ctx *context.Context, orderId string, order *kite.Order) error {
+	if b.PreTradeCheck != nil {
+		if err := b.PreTradeCheck(order); err != nil {
+			return err
+		}
+	}
+
+	b.mutex.Lock()
+	o, exists := b.orders[orderId]
+	if !exists {
+		b.mutex.Unlock()
+		return errors.New("order_not_found")
+	}
+	if !isPending(o.status) {
+		b.mutex.Unlock()
+		return fmt.Errorf("order %v is %v and cannot be modified", orderId, o.status.OrderState)
+	}
+	if kite.BrokerInstrumentTokens == nil {
+		b.mutex.Unlock()
+		return errors.New("instruments_not_loaded")
+	}
+	symbolKey := o.status.Exchange + ":" + o.status.TradingSymbol
+	instrument, exists := (*kite.BrokerInstrumentTokens)[symbolKey]
+	if !exists {
+		b.mutex.Unlock()
+		return fmt.Errorf("instrument %s not found", symbolKey)
+	}
+	modified := *o.status
+	err := applyOrder(&modified, order, instrument)
+	if err == nil {
+		err = b.checkTrigger(&modified)
+	}
+	if err != nil {
+		b.mutex.Unlock()
+		return err
+	}
+	*o.status = modified
+	o.status.Modified = true
+	o.record()
+	b.mutex.Unlock()
+
+	b.match()
+	return nil
+}
+
+func (b *Broker) CancelOrder(ctx *context.Context, orderId string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	o, exists := b.orders[orderId]
+	if !exists {
+		return errors.New("order_not_found")
+	}
+	if !isPending(o.status) {
+		return fmt.Errorf("order %v is %v and cannot be cancelled", orderId, o.status.OrderState)
+	}
+	o.status.CancelledQuantity = o.status.PendingQuantity
+	o.status.PendingQuantity = 0
+	o.status.OrderState = "CANCELLED"
+	o.record()
+	return nil
+}
+
+func (b *Broker) GetOrders(ctx *context.Context) ([]*kite.OrderStatus, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	orders := []*kite.OrderStatus{}
+	for _, id := range b.orderIds {
+		snapshot := *b.orders[id].status
+		orders = append(orders, &snapshot)
+	}
+	return orders, nil
+}
+
+func (b *Broker) GetOrderHistory(ctx *context.Context, orderId string) ([]*kite.OrderStatus, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	o, exists := b.orders[orderId]
+	if !exists {
+		return nil, errors.New("order_not_found")
+	}
+	history := make([]*kite.OrderStatus, len(o.history))
+	copy(history, o.history)
+	return history, nil
+}
+
+func (b *Broker) GetTrades(ctx *context.Context) ([]*kite.Trade, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	trades := make([]*kite.Trade, len(b.trades))
+	copy(trades, b.trades)
+	return trades, nil
+}
+
+func (b *Broker) GetOrderTrades(ctx *context.Context, orderId string) ([]*kite.Trade, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.orders[orderId]; !exists {
+		return nil, errors.New("order_not_found")
+	}
+	trades := []*kite.Trade{}
+	for _, trade := range b.trades {
+		if trade.OrderId == orderId {
+			trades = append(trades, trade)
+		}
+	}
+	return trades, nil
+}
+
+// checkTrigger rejects SL orders whose trigger the market has already
+// crossed, as the exchange would
+func (b *Broker) checkTrigger(status *kite.OrderStatus) error {
+	if status.OrderState != "TRIGGER PENDING" {
+		return nil
+	}
+	b.TickSymbolMapMutex.RLock()
+	ticker, ok := b.TickSymbolMap[status.Exchange+":"+status.TradingSymbol]
+	b.TickSymbolMapMutex.RUnlock()
+	if !ok || ticker.LastPrice <= 0 || !b.GetQuotePolicy().Fresh(ticker) {
+		return nil
+	}
+	if status.TransactionType == "BUY" && status.TriggerPrice <= ticker.LastPrice {
+		return errors.New("trigger price for stoploss buy orders should be higher than the last traded price")
+	}
+	if status.TransactionType == "SELL" && status.TriggerPrice >= ticker.LastPrice {
+		return errors.New("trigger price for stoploss sell orders should be lower than the last traded price")
+	}
+	return nil
+}
+
+// applyOrder copies quantity and pricing from order onto status following
+// the same LIMIT, MARKET and SL semantics as Kite.PlaceOrder
+func applyOrder(status *kite.OrderStatus, order *kite.Order, instrument *kite.Instrument) error {
+	if order.Quantity <= 0 {
+		return errors.New("quantity_not_allowed")
+	}
+
+	tickSize := order.TickSize
+	if tickSize <= 0 && instrument != nil {
+		tickSize = instrument.TickSize
+	}
+	if tickSize <= 0 {
+		tickSize = 0.05
+	}
+	mpp := order.MarketProtectionPercentage
+
+	switch order.OrderType {
+	case "LIMIT":
+		status.OrderState = "OPEN"
+		status.Price = order.Price
+		status.TriggerPrice = 0
+	case "MARKET":
+		status.OrderState = "OPEN"
+		status.Price = 0
+		status.TriggerPrice = 0
+	case "SL":
+		status.OrderState = "TRIGGER PENDING"
+		status.TriggerPrice = order.Price
+		if order.TransactionType == "BUY" {
+			status.Price = math.Floor((order.Price*(1+mpp/100))/tickSize) * tickSize
+		} else {
+			status.Price = math.Floor((order.Price*(1-mpp/100))/tickSize) * tickSize
+		}
+	default:
+		return errors.New("order_type_not_allowed")
+	}
+
+	status.OrderType = order.OrderType
+	status.Quantity = uint32(order.Quantity)
+	status.PendingQuantity = status.Quantity - status.FilledQuantity
+	return nil
+}
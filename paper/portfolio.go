@@ -0,0 +1,177 @@
+package paper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+// GetPositions marks the simulated positions to the latest ticks. Every paper
+// position is opened during the session, so net and day carry the same rows.
+func (b *Broker) GetPositions(ctx *context.Context) (*kite.Positions, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	positions := &kite.Positions{Net: []*kite.Position{}, Day: []*kite.Position{}}
+	for _, position := range b.positions {
+		b.TickSymbolMapMutex.RLock()
+		ticker, ok := b.TickSymbolMap[position.Exchange+":"+position.TradingSymbol]
+		b.TickSymbolMapMutex.RUnlock()
+		if ok && ticker.LastPrice > 0 {
+			position.LastPrice = ticker.LastPrice
+		}
+		position.Pnl = position.MTM(position.LastPrice)
+		positions.Pnl += position.Pnl
+
+		net := *position
+		day := *position
+		positions.Net = append(positions.Net, &net)
+		positions.Day = append(positions.Day, &day)
+	}
+	for _, rows := range [][]*kite.Position{positions.Net, positions.Day} {
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i].TradingSymbol < rows[j].TradingSymbol
+		})
+	}
+	return positions, nil
+}
+
+// ConvertPosition moves quantity of a simulated position from oldProduct to
+// newProduct at its average price
+func (b *Broker) ConvertPosition(ctx *context.Context, position *kite.Position, oldProduct string, newProduct string, quantity uint32) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	key := position.Exchange + ":" + position.TradingSymbol + ":"
+	current, ok := b.positions[key+oldProduct]
+	if !ok {
+		return errors.New("position_not_found")
+	}
+	err := kite.ValidateConversion(current, oldProduct, newProduct, quantity)
+	if err != nil {
+		return err
+	}
+
+	target, ok := b.positions[key+newProduct]
+	if !ok {
+		target = &kite.Position{
+			TradingSymbol:   current.TradingSymbol,
+			Exchange:        current.Exchange,
+			InstrumentToken: current.InstrumentToken,
+			Product:         newProduct,
+			Multiplier:      current.Multiplier,
+			LastPrice:       current.LastPrice,
+		}
+		b.positions[key+newProduct] = target
+	}
+
+	side := "BUY"
+	if current.Quantity < 0 {
+		side = "SELL"
+	}
+	moved := int64(quantity)
+	value := float64(moved) * float64(current.Multiplier) * current.AveragePrice
+	addFill(current, side, -moved, -value)
+	addFill(target, side, moved, value)
+	if current.BuyQuantity == 0 && current.SellQuantity == 0 {
+		delete(b.positions, key+oldProduct)
+	}
+	return nil
+}
+
+// GetMargin reports the notional of open positions as used margin against
+// the starting capital adjusted for P&L and estimated charges
+func (b *Broker) GetMargin(ctx *context.Context) (*kite.Margin, error) {
+	positions, err := b.GetPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	used := 0.0
+	for _, position := range b.positions {
+		used += math.Abs(float64(position.Quantity)) * position.AveragePrice * float64(position.Multiplier)
+	}
+	total := b.Capital + positions.Pnl - b.charges
+	return &kite.Margin{
+		MarginUsed:  used,
+		MarginTotal: total,
+		Equity: &kite.SegmentMargin{
+			Enabled: true,
+			Net:     total - used,
+			Available: &kite.AvailableMargin{
+				Cash:           b.Capital,
+				OpeningBalance: b.Capital,
+				LiveBalance:    total - used,
+			},
+			Utilised: &kite.UtilisedMargin{
+				Debits:        used,
+				Exposure:      used,
+				M2MUnrealised: positions.Pnl,
+			},
+		},
+		Commodity: &kite.SegmentMargin{
+			Available: &kite.AvailableMargin{},
+			Utilised:  &kite.UtilisedMargin{},
+		},
+	}, nil
+}
+
+// GetSegmentMargin returns one segment of the simulated margin; paper
+// positions are all booked against equity
+func (b *Broker) GetSegmentMargin(ctx *context.Context, segment string) (*kite.SegmentMargin, error) {
+	if segment != "equity" && segment != "commodity" {
+		return nil, errors.New("segment_not_allowed")
+	}
+	margin, err := b.GetMargin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if segment == "commodity" {
+		return margin.Commodity, nil
+	}
+	return margin.Equity, nil
+}
+
+func (b *Broker) GetHoldings(ctx *context.Context) ([]*kite.Holding, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	holdings := make([]*kite.Holding, len(b.Holdings))
+	copy(holdings, b.Holdings)
+	return holdings, nil
+}
+
+// GetOrderCharges estimates the charges of every filled order with the
+// offline calculator
+func (b *Broker) GetOrderCharges(ctx *context.Context) ([]*kite.BrokerCharges, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	orderCharges := []*kite.BrokerCharges{}
+	for _, id := range b.orderIds {
+		s := b.orders[id].status
+		if s.OrderState != "COMPLETE" {
+			continue
+		}
+		charges, err := b.Calculator.Calculate(kite.ChargesRequestFromOrder(s))
+		if err != nil {
+			return nil, err
+		}
+		orderCharges = append(orderCharges, charges)
+	}
+	return orderCharges, nil
+}
+
+// GetCharges returns the estimated charges of all simulated fills
+func (b *Broker) GetCharges(ctx *context.Context) (float64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.charges, nil
+}
@@ -0,0 +1,284 @@
+package pnl
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+const DefaultRefreshInterval = 30 * time.Second
+const DefaultBufferSize = 100
+
+// HoldingProduct is the product holdings are aggregated under
+const HoldingProduct = "HOLDING"
+
+// InstrumentPnl is the P&L of one position or holding at its last price
+type InstrumentPnl struct {
+	Exchange        string  `json:"exchange"`
+	TradingSymbol   string  `json:"tradingsymbol"`
+	InstrumentToken uint32  `json:"instrument_token"`
+	Product         string  `json:"product"`
+	Underlying      string  `json:"underlying"`
+	Quantity        int64   `json:"quantity"`
+	LastPrice       float64 `json:"last_price"`
+	Realised        float64 `json:"realised"`
+	Unrealised      float64 `json:"unrealised"`
+	Pnl             float64 `json:"pnl"`
+}
+
+type Aggregate struct {
+	Realised   float64 `json:"realised"`
+	Unrealised float64 `json:"unrealised"`
+	Pnl        float64 `json:"pnl"`
+}
+
+// Update is emitted on every tick of a tracked instrument
+type Update struct {
+	Time         time.Time             `json:"time"`
+	Token        uint32                `json:"token"`
+	Instruments  []*InstrumentPnl      `json:"instruments"`
+	ByProduct    map[string]*Aggregate `json:"by_product"`
+	ByUnderlying map[string]*Aggregate `json:"by_underlying"`
+	Total        *Aggregate            `json:"total"`
+}
+
+// Tracker streams the P&L of net positions and holdings. Positions and
+// holdings are reloaded every RefreshInterval and their tokens subscribed on
+// Ticker; every tick of a tracked token publishes an Update on Updates.
+type Tracker struct {
+	Portfolio       kite.Portfolio
+	Ticker          *kite.TickerClient
+	RefreshInterval time.Duration
+	Updates         chan *Update
+
+	mutex      sync.RWMutex
+	positions  []*kite.Position
+	holdings   []*kite.Holding
+	lastPrices map[uint32]float64
+	latest     *Update
+}
+
+// NewTracker returns a tracker over the portfolio. The ticker should be a
+// dedicated client as the tracker drains its TickerChan.
+func NewTracker(portfolio kite.Portfolio, ticker *kite.TickerClient) *Tracker {
+	return &Tracker{
+		Portfolio:       portfolio,
+		Ticker:          ticker,
+		RefreshInterval: DefaultRefreshInterval,
+		Updates:         make(chan *Update, DefaultBufferSize),
+		lastPrices:      map[uint32]float64{},
+	}
+}
+
+// Run loads the portfolio and publishes updates until the context is cancelled
+func (t *Tracker) Run(ctx *context.Context) {
+	err := t.refresh(ctx)
+	if err != nil {
+		log.Errorf("pnl : failed to load portfolio -> %v", err)
+	}
+	t.publish(0)
+
+	refresh := time.NewTicker(t.RefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-(*ctx).Done():
+			return
+		case <-t.Ticker.ConnectChan:
+			t.subscribe(ctx)
+		case <-refresh.C:
+			err := t.refresh(ctx)
+			if err != nil {
+				log.Errorf("pnl : failed to refresh portfolio -> %v", err)
+				continue
+			}
+			t.publish(0)
+		case ticker := <-t.Ticker.TickerChan:
+			t.mutex.Lock()
+			_, tracked := t.lastPrices[ticker.Token]
+			if tracked && ticker.LastPrice > 0 {
+				t.lastPrices[ticker.Token] = ticker.LastPrice
+			}
+			t.mutex.Unlock()
+			if tracked {
+				t.publish(ticker.Token)
+			}
+		}
+	}
+}
+
+// Latest returns the most recent update, nil before the first one
+func (t *Tracker) Latest() *Update {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.latest
+}
+
+func (t *Tracker) refresh(ctx *context.Context) error {
+	positions, err := t.Portfolio.GetPositions(ctx)
+	if err != nil {
+		return err
+	}
+	holdings, err := t.Portfolio.GetHoldings(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.positions = positions.Net
+	t.holdings = holdings
+	lastPrices := map[uint32]float64{}
+	for _, position := range t.positions {
+		lastPrices[position.InstrumentToken] = position.LastPrice
+	}
+	for _, holding := range t.holdings {
+		lastPrices[holding.InstrumentToken] = holding.LastPrice
+	}
+	for token := range lastPrices {
+		if price, ok := t.lastPrices[token]; ok && price > 0 {
+			lastPrices[token] = price
+		}
+	}
+	t.lastPrices = lastPrices
+	t.mutex.Unlock()
+
+	t.subscribe(ctx)
+	return nil
+}
+
+func (t *Tracker) subscribe(ctx *context.Context) {
+	t.mutex.RLock()
+	tokens := []uint32{}
+	for token := range t.lastPrices {
+		tokens = append(tokens, token)
+	}
+	t.mutex.RUnlock()
+
+	if len(tokens) == 0 {
+		return
+	}
+	err := t.Ticker.SubscribeLTP(ctx, tokens)
+	if err != nil {
+		log.Errorf("pnl : failed to subscribe portfolio -> %v", err)
+	}
+}
+
+// publish computes an update and sends it without blocking; updates are
+// dropped while the consumer is behind
+func (t *Tracker) publish(token uint32) {
+	t.mutex.Lock()
+	update := &Update{
+		Time:         clock.Now(),
+		Token:        token,
+		Instruments:  []*InstrumentPnl{},
+		ByProduct:    map[string]*Aggregate{},
+		ByUnderlying: map[string]*Aggregate{},
+		Total:        &Aggregate{},
+	}
+	for _, position := range t.positions {
+		update.add(positionPnl(position, t.lastPrices[position.InstrumentToken]))
+	}
+	for _, holding := range t.holdings {
+		update.add(holdingPnl(holding, t.lastPrices[holding.InstrumentToken]))
+	}
+	t.latest = update
+	t.mutex.Unlock()
+
+	select {
+	case t.Updates <- update:
+	default:
+	}
+}
+
+func (update *Update) add(instrument *InstrumentPnl) {
+	update.Instruments = append(update.Instruments, instrument)
+	for _, aggregate := range []*Aggregate{
+		update.aggregate(update.ByProduct, instrument.Product),
+		update.aggregate(update.ByUnderlying, instrument.Underlying),
+		update.Total,
+	} {
+		aggregate.Realised += instrument.Realised
+		aggregate.Unrealised += instrument.Unrealised
+		aggregate.Pnl += instrument.Pnl
+	}
+}
+
+func (update *Update) aggregate(aggregates map[string]*Aggregate, key string) *Aggregate {
+	aggregate, ok := aggregates[key]
+	if !ok {
+		aggregate = &Aggregate{}
+		aggregates[key] = aggregate
+	}
+	return aggregate
+}
+
+// positionPnl splits the position MTM into the realised P&L of the closed
+// quantity and the unrealised P&L of the open quantity
+func positionPnl(position *kite.Position, lastPrice float64) *InstrumentPnl {
+	if lastPrice <= 0 {
+		lastPrice = position.LastPrice
+	}
+	total := position.MTM(lastPrice)
+	realised := total
+	if position.Quantity != 0 {
+		realised = 0
+		closed := math.Min(float64(position.BuyQuantity), float64(position.SellQuantity))
+		if closed > 0 {
+			realised = closed * (position.SellValue/float64(position.SellQuantity) - position.BuyValue/float64(position.BuyQuantity))
+		}
+	}
+	return &InstrumentPnl{
+		Exchange:        position.Exchange,
+		TradingSymbol:   position.TradingSymbol,
+		InstrumentToken: position.InstrumentToken,
+		Product:         position.Product,
+		Underlying:      Underlying(position.Exchange, position.TradingSymbol),
+		Quantity:        position.Quantity,
+		LastPrice:       lastPrice,
+		Realised:        realised,
+		Unrealised:      total - realised,
+		Pnl:             total,
+	}
+}
+
+func holdingPnl(holding *kite.Holding, lastPrice float64) *InstrumentPnl {
+	if lastPrice <= 0 {
+		lastPrice = holding.LastPrice
+	}
+	quantity := holding.Quantity + holding.T1Quantity
+	unrealised := float64(quantity) * (lastPrice - holding.AveragePrice)
+	return &InstrumentPnl{
+		Exchange:        holding.Exchange,
+		TradingSymbol:   holding.TradingSymbol,
+		InstrumentToken: holding.InstrumentToken,
+		Product:         HoldingProduct,
+		Underlying:      Underlying(holding.Exchange, holding.TradingSymbol),
+		Quantity:        quantity,
+		LastPrice:       lastPrice,
+		Unrealised:      unrealised,
+		Pnl:             unrealised,
+	}
+}
+
+// Underlying returns the underlying name of a derivative from the instrument
+// master, and the trading symbol itself for cash instruments
+func Underlying(exchange string, tradingSymbol string) string {
+	if kite.BrokerInstrumentTokens == nil {
+		return tradingSymbol
+	}
+	instrument, ok := (*kite.BrokerInstrumentTokens)[exchange+":"+tradingSymbol]
+	if !ok || instrument.Name == "" {
+		return tradingSymbol
+	}
+	switch instrument.InstrumentType {
+	case "FUT", "CE", "PE":
+		return instrument.Name
+	}
+	return tradingSymbol
+}
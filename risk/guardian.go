@@ -0,0 +1,283 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/souvik131/kite-go-library/clock"
+	"github.com/souvik131/kite-go-library/kite"
+)
+
+const DefaultRefreshInterval = 30 * time.Second
+const DefaultMarketProtectionPercentage float64 = 2
+
+var ErrEntriesBlocked = errors.New("mtm_guardian_entries_blocked")
+
+// Guardian recomputes the session mark-to-market of all positions from live
+// ticks. Once the MTM breaches StopLoss or Target it squares off every open
+// position and blocks new entries for the rest of the day. The square-off is
+// retried on every refresh until the net positions are flat.
+type Guardian struct {
+	Broker                     kite.Broker
+	Ticker                     *kite.TickerClient
+	StopLoss                   float64
+	Target                     float64
+	RefreshInterval            time.Duration
+	MarketProtectionPercentage float64
+
+	mutex        sync.RWMutex
+	positions    []*kite.Position
+	lastPrices   map[uint32]float64
+	mtm          float64
+	blockedUntil time.Time
+	squaringOff  bool
+}
+
+// NewGuardian returns a guardian that squares off once the loss reaches
+// stopLoss or the profit reaches target. A zero threshold is disabled.
+// The ticker should be a dedicated client as the guardian drains its
+// TickerChan.
+func NewGuardian(broker kite.Broker, ticker *kite.TickerClient, stopLoss float64, target float64) *Guardian {
+	return &Guardian{
+		Broker:                     broker,
+		Ticker:                     ticker,
+		StopLoss:                   math.Abs(stopLoss),
+		Target:                     math.Abs(target),
+		RefreshInterval:            DefaultRefreshInterval,
+		MarketProtectionPercentage: DefaultMarketProtectionPercentage,
+		lastPrices:                 map[uint32]float64{},
+	}
+}
+
+// Run installs the pre-trade check on the broker and watches ticks until the
+// context is cancelled.
+func (g *Guardian) Run(ctx *context.Context) {
+	g.Broker.SetPreTradeCheck(g.CheckOrder)
+
+	err := g.refresh(ctx)
+	if err != nil {
+		log.Errorf("guardian : failed to load positions -> %v", err)
+	}
+
+	refresh := time.NewTicker(g.RefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-(*ctx).Done():
+			return
+		case <-g.Ticker.ConnectChan:
+			g.subscribe(ctx)
+		case <-refresh.C:
+			err := g.refresh(ctx)
+			if err != nil {
+				log.Errorf("guardian : failed to refresh positions -> %v", err)
+				continue
+			}
+			g.evaluate(ctx)
+			g.retrySquareOff(ctx)
+		case ticker := <-g.Ticker.TickerChan:
+			g.mutex.Lock()
+			g.lastPrices[ticker.Token] = ticker.LastPrice
+			g.mutex.Unlock()
+			g.evaluate(ctx)
+		}
+	}
+}
+
+// MTM returns the last computed session mark-to-market.
+func (g *Guardian) MTM() float64 {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.mtm
+}
+
+// Blocked reports whether new entries are blocked after a square-off.
+func (g *Guardian) Blocked() bool {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return clock.Now().Before(g.blockedUntil)
+}
+
+// CheckOrder rejects orders that open or add to a position while entries are
+// blocked. Orders that only reduce an existing position are allowed through
+// so that exits keep working.
+func (g *Guardian) CheckOrder(order *kite.Order) error {
+	if !g.Blocked() {
+		return nil
+	}
+
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	for _, position := range g.positions {
+		if position.Exchange != order.Exchange || position.TradingSymbol != order.TradingSymbol || position.Product != order.Product {
+			continue
+		}
+		if order.TransactionType == "BUY" && position.Quantity < 0 && order.Quantity <= float64(-position.Quantity) {
+			return nil
+		}
+		if order.TransactionType == "SELL" && position.Quantity > 0 && order.Quantity <= float64(position.Quantity) {
+			return nil
+		}
+	}
+	return ErrEntriesBlocked
+}
+
+// SquareOff cancels pending orders and closes every open position at market.
+// Positions are fetched after the cancellations so fills in the meantime are
+// not closed twice. The exits go out even on a stale quote, as waiting for a
+// fresh one would leave the positions open.
+func (g *Guardian) SquareOff(ctx *context.Context) error {
+	orders, err := g.Broker.GetOrders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		if order.OrderState == "OPEN" || order.OrderState == "TRIGGER PENDING" {
+			err := g.Broker.CancelOrder(ctx, order.OrderId)
+			if err != nil {
+				log.Errorf("guardian : failed to cancel order %v -> %v", order.OrderId, err)
+			}
+		}
+	}
+
+	fetched, err := g.Broker.GetPositions(ctx)
+	if err != nil {
+		return err
+	}
+	positions := fetched.Net
+	g.mutex.Lock()
+	g.positions = positions
+	g.mutex.Unlock()
+
+	var lastErr error
+	for _, position := range positions {
+		if position.Quantity == 0 {
+			continue
+		}
+		order := &kite.Order{
+			Exchange:                   position.Exchange,
+			TradingSymbol:              position.TradingSymbol,
+			Quantity:                   math.Abs(float64(position.Quantity)),
+			Product:                    position.Product,
+			OrderType:                  "MARKET",
+			TransactionType:            "SELL",
+			MarketProtectionPercentage: g.MarketProtectionPercentage,
+			TickSize:                   0.05,
+			AllowStaleQuote:            true,
+		}
+		if position.Quantity < 0 {
+			order.TransactionType = "BUY"
+		}
+		if kite.BrokerInstrumentTokens != nil {
+			if instrument, ok := (*kite.BrokerInstrumentTokens)[position.Exchange+":"+position.TradingSymbol]; ok && instrument.TickSize > 0 {
+				order.TickSize = instrument.TickSize
+			}
+		}
+		_, err := g.Broker.PlaceOrder(ctx, order)
+		if err != nil {
+			log.Errorf("guardian : failed to square off %v -> %v", position.TradingSymbol, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (g *Guardian) refresh(ctx *context.Context) error {
+	positions, err := g.Broker.GetPositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	g.positions = positions.Net
+	for _, position := range g.positions {
+		if _, ok := g.lastPrices[position.InstrumentToken]; !ok {
+			g.lastPrices[position.InstrumentToken] = position.LastPrice
+		}
+	}
+	g.mutex.Unlock()
+
+	g.subscribe(ctx)
+	return nil
+}
+
+func (g *Guardian) subscribe(ctx *context.Context) {
+	g.mutex.RLock()
+	tokens := []uint32{}
+	for _, position := range g.positions {
+		tokens = append(tokens, position.InstrumentToken)
+	}
+	g.mutex.RUnlock()
+
+	if len(tokens) == 0 {
+		return
+	}
+	err := g.Ticker.SubscribeLTP(ctx, tokens)
+	if err != nil {
+		log.Errorf("guardian : failed to subscribe positions -> %v", err)
+	}
+}
+
+func (g *Guardian) evaluate(ctx *context.Context) {
+	g.mutex.Lock()
+	mtm := 0.0
+	for _, position := range g.positions {
+		mtm += position.MTM(g.lastPrices[position.InstrumentToken])
+	}
+	g.mtm = mtm
+
+	now := clock.Now()
+	if now.Before(g.blockedUntil) {
+		g.mutex.Unlock()
+		return
+	}
+	breached := (g.StopLoss > 0 && mtm <= -g.StopLoss) || (g.Target > 0 && mtm >= g.Target)
+	if !breached {
+		g.mutex.Unlock()
+		return
+	}
+	year, month, day := now.Date()
+	g.blockedUntil = time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+	g.squaringOff = true
+	g.mutex.Unlock()
+
+	log.Warnf("guardian : mtm %.2f breached limits (stop loss %.2f, target %.2f), squaring off", mtm, g.StopLoss, g.Target)
+	err := g.SquareOff(ctx)
+	if err != nil {
+		log.Errorf("guardian : square off incomplete -> %v", err)
+	}
+}
+
+// retrySquareOff squares off again after a breach until the freshly
+// refreshed net positions are flat
+func (g *Guardian) retrySquareOff(ctx *context.Context) {
+	g.mutex.Lock()
+	if !g.squaringOff {
+		g.mutex.Unlock()
+		return
+	}
+	open := 0
+	for _, position := range g.positions {
+		if position.Quantity != 0 {
+			open++
+		}
+	}
+	if open == 0 {
+		g.squaringOff = false
+		g.mutex.Unlock()
+		log.Infof("guardian : all positions squared off")
+		return
+	}
+	g.mutex.Unlock()
+
+	log.Warnf("guardian : %v positions still open, retrying square off", open)
+	err := g.SquareOff(ctx)
+	if err != nil {
+		log.Errorf("guardian : square off incomplete -> %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"bytes"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compress zstd-compresses input at the best compression level
+func Compress(input []byte) ([]byte, error) {
+	var b bytes.Buffer
+	bestLevel := zstd.WithEncoderLevel(zstd.SpeedBestCompression)
+	encoder, err := zstd.NewWriter(&b, bestLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = encoder.Write(input)
+	if err != nil {
+		encoder.Close()
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Decompress reverses Compress
+func Decompress(input []byte) ([]byte, error) {
+	b := bytes.NewReader(input)
+	decoder, err := zstd.NewReader(b)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	var out bytes.Buffer
+	_, err = out.ReadFrom(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
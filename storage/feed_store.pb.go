@@ -246,6 +246,8 @@ type Ticker struct {
 	LotSize             uint32                 `protobuf:"varint,17,opt,name=LotSize,proto3" json:"LotSize,omitempty"`
 	ExchangeTimestamp   uint32                 `protobuf:"varint,18,opt,name=ExchangeTimestamp,proto3" json:"ExchangeTimestamp,omitempty"`
 	Depth               *Depth                 `protobuf:"bytes,19,opt,name=Depth,proto3" json:"Depth,omitempty"`
+	Segment             uint32                 `protobuf:"varint,20,opt,name=Segment,proto3" json:"Segment,omitempty"`
+	Divisor             uint32                 `protobuf:"varint,21,opt,name=Divisor,proto3" json:"Divisor,omitempty"`
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
@@ -413,6 +415,20 @@ func (x *Ticker) GetDepth() *Depth {
 	return nil
 }
 
+func (x *Ticker) GetSegment() uint32 {
+	if x != nil {
+		return x.Segment
+	}
+	return 0
+}
+
+func (x *Ticker) GetDivisor() uint32 {
+	if x != nil {
+		return x.Divisor
+	}
+	return 0
+}
+
 type Depth struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Buy           []*Order               `protobuf:"bytes,1,rep,name=Buy,proto3" json:"Buy,omitempty"`
@@ -525,6 +541,166 @@ func (x *Order) GetOrders() uint32 {
 	return 0
 }
 
+type CandleDay struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         uint32                 `protobuf:"varint,1,opt,name=Token,proto3" json:"Token,omitempty"`
+	Interval      string                 `protobuf:"bytes,2,opt,name=Interval,proto3" json:"Interval,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=Date,proto3" json:"Date,omitempty"`
+	Candles       []*Candle              `protobuf:"bytes,4,rep,name=Candles,proto3" json:"Candles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CandleDay) Reset() {
+	*x = CandleDay{}
+	mi := &file_storage_feed_store_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CandleDay) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CandleDay) ProtoMessage() {}
+
+func (x *CandleDay) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_feed_store_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CandleDay.ProtoReflect.Descriptor instead.
+func (*CandleDay) Descriptor() ([]byte, []int) {
+	return file_storage_feed_store_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CandleDay) GetToken() uint32 {
+	if x != nil {
+		return x.Token
+	}
+	return 0
+}
+
+func (x *CandleDay) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *CandleDay) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *CandleDay) GetCandles() []*Candle {
+	if x != nil {
+		return x.Candles
+	}
+	return nil
+}
+
+type Candle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     int64                  `protobuf:"varint,1,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	Open          float64                `protobuf:"fixed64,2,opt,name=Open,proto3" json:"Open,omitempty"`
+	High          float64                `protobuf:"fixed64,3,opt,name=High,proto3" json:"High,omitempty"`
+	Low           float64                `protobuf:"fixed64,4,opt,name=Low,proto3" json:"Low,omitempty"`
+	Close         float64                `protobuf:"fixed64,5,opt,name=Close,proto3" json:"Close,omitempty"`
+	Volume        uint64                 `protobuf:"varint,6,opt,name=Volume,proto3" json:"Volume,omitempty"`
+	OI            uint64                 `protobuf:"varint,7,opt,name=OI,proto3" json:"OI,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Candle) Reset() {
+	*x = Candle{}
+	mi := &file_storage_feed_store_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Candle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Candle) ProtoMessage() {}
+
+func (x *Candle) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_feed_store_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Candle.ProtoReflect.Descriptor instead.
+func (*Candle) Descriptor() ([]byte, []int) {
+	return file_storage_feed_store_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Candle) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Candle) GetOpen() float64 {
+	if x != nil {
+		return x.Open
+	}
+	return 0
+}
+
+func (x *Candle) GetHigh() float64 {
+	if x != nil {
+		return x.High
+	}
+	return 0
+}
+
+func (x *Candle) GetLow() float64 {
+	if x != nil {
+		return x.Low
+	}
+	return 0
+}
+
+func (x *Candle) GetClose() float64 {
+	if x != nil {
+		return x.Close
+	}
+	return 0
+}
+
+func (x *Candle) GetVolume() uint64 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *Candle) GetOI() uint64 {
+	if x != nil {
+		return x.OI
+	}
+	return 0
+}
+
 var File_storage_feed_store_proto protoreflect.FileDescriptor
 
 var file_storage_feed_store_proto_rawDesc = string([]byte{
@@ -555,7 +731,7 @@ var file_storage_feed_store_proto_rawDesc = string([]byte{
 	0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x12, 0x29, 0x0a, 0x07, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x72,
 	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
 	0x65, 0x2e, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x72, 0x52, 0x07, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x72,
-	0x73, 0x22, 0xca, 0x04, 0x0a, 0x06, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05,
+	0x73, 0x22, 0xfe, 0x04, 0x0a, 0x06, 0x54, 0x69, 0x63, 0x6b, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05,
 	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x54, 0x6f, 0x6b,
 	0x65, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x4c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65, 0x18,
 	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x4c, 0x61, 0x73, 0x74, 0x50, 0x72, 0x69, 0x63, 0x65,
@@ -591,19 +767,40 @@ var file_storage_feed_store_proto_rawDesc = string([]byte{
 	0x12, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x45, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x54,
 	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x24, 0x0a, 0x05, 0x44, 0x65, 0x70, 0x74,
 	0x68, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
-	0x65, 0x2e, 0x44, 0x65, 0x70, 0x74, 0x68, 0x52, 0x05, 0x44, 0x65, 0x70, 0x74, 0x68, 0x22, 0x4d,
-	0x0a, 0x05, 0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x20, 0x0a, 0x03, 0x42, 0x75, 0x79, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x4f,
-	0x72, 0x64, 0x65, 0x72, 0x52, 0x03, 0x42, 0x75, 0x79, 0x12, 0x22, 0x0a, 0x04, 0x53, 0x65, 0x6c,
-	0x6c, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
-	0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x04, 0x53, 0x65, 0x6c, 0x6c, 0x22, 0x51, 0x0a,
-	0x05, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x51, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08,
-	0x51, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x4f, 0x72, 0x64, 0x65,
-	0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x73,
-	0x42, 0x13, 0x5a, 0x11, 0x2e, 0x2f, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x3b, 0x73, 0x74,
-	0x6f, 0x72, 0x61, 0x67, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x2e, 0x44, 0x65, 0x70, 0x74, 0x68, 0x52, 0x05, 0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x18,
+	0x0a, 0x07, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x44, 0x69, 0x76, 0x69,
+	0x73, 0x6f, 0x72, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x44, 0x69, 0x76, 0x69, 0x73,
+	0x6f, 0x72, 0x22, 0x4d, 0x0a, 0x05, 0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x20, 0x0a, 0x03, 0x42,
+	0x75, 0x79, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x03, 0x42, 0x75, 0x79, 0x12, 0x22, 0x0a,
+	0x04, 0x53, 0x65, 0x6c, 0x6c, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x04, 0x53, 0x65, 0x6c,
+	0x6c, 0x22, 0x51, 0x0a, 0x05, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x50, 0x72,
+	0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x50, 0x72, 0x69, 0x63, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x51, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x51, 0x75, 0x61, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x16, 0x0a, 0x06,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x4f, 0x72,
+	0x64, 0x65, 0x72, 0x73, 0x22, 0x7c, 0x0a, 0x09, 0x43, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x44, 0x61,
+	0x79, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x05, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x49, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x49, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x44, 0x61, 0x74, 0x65, 0x12, 0x29, 0x0a, 0x07, 0x43, 0x61, 0x6e, 0x64, 0x6c,
+	0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x43, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x52, 0x07, 0x43, 0x61, 0x6e, 0x64, 0x6c,
+	0x65, 0x73, 0x22, 0x9e, 0x01, 0x0a, 0x06, 0x43, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x12, 0x1c, 0x0a,
+	0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x4f,
+	0x70, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x4f, 0x70, 0x65, 0x6e, 0x12,
+	0x12, 0x0a, 0x04, 0x48, 0x69, 0x67, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x48,
+	0x69, 0x67, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x4c, 0x6f, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x03, 0x4c, 0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x56,
+	0x6f, 0x6c, 0x75, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x56, 0x6f, 0x6c,
+	0x75, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x4f, 0x49, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x02, 0x4f, 0x49, 0x42, 0x13, 0x5a, 0x11, 0x2e, 0x2f, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x3b, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -618,7 +815,7 @@ func file_storage_feed_store_proto_rawDescGZIP() []byte {
 	return file_storage_feed_store_proto_rawDescData
 }
 
-var file_storage_feed_store_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_storage_feed_store_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_storage_feed_store_proto_goTypes = []any{
 	(*Map)(nil),       // 0: storage.Map
 	(*TickerMap)(nil), // 1: storage.TickerMap
@@ -626,6 +823,8 @@ var file_storage_feed_store_proto_goTypes = []any{
 	(*Ticker)(nil),    // 3: storage.Ticker
 	(*Depth)(nil),     // 4: storage.Depth
 	(*Order)(nil),     // 5: storage.Order
+	(*CandleDay)(nil), // 6: storage.CandleDay
+	(*Candle)(nil),    // 7: storage.Candle
 }
 var file_storage_feed_store_proto_depIdxs = []int32{
 	1, // 0: storage.Map.TickerMap:type_name -> storage.TickerMap
@@ -633,11 +832,12 @@ var file_storage_feed_store_proto_depIdxs = []int32{
 	4, // 2: storage.Ticker.Depth:type_name -> storage.Depth
 	5, // 3: storage.Depth.Buy:type_name -> storage.Order
 	5, // 4: storage.Depth.Sell:type_name -> storage.Order
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	7, // 5: storage.CandleDay.Candles:type_name -> storage.Candle
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_storage_feed_store_proto_init() }
@@ -651,7 +851,7 @@ func file_storage_feed_store_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_storage_feed_store_proto_rawDesc), len(file_storage_feed_store_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
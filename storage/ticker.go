@@ -0,0 +1,22 @@
+package storage
+
+// PriceDivisor converts the ticker's stored prices to rupees. Records written
+// before Divisor was stored fall back to the segment in the token's low
+// byte: 3 (NSE currency) and 6 (BSE currency) carry extra decimals.
+func (x *Ticker) PriceDivisor() float64 {
+	if x.GetDivisor() > 0 {
+		return float64(x.GetDivisor())
+	}
+	switch x.GetToken() & 0xff {
+	case 3:
+		return 10000000
+	case 6:
+		return 10000
+	}
+	return 100
+}
+
+// Price converts a stored price of the ticker to rupees
+func (x *Ticker) Price(value uint32) float64 {
+	return float64(value) / x.PriceDivisor()
+}